@@ -0,0 +1,156 @@
+package gollama
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// === BM25 keyword index ===
+
+// BM25Index is an inverted index scoring the records with the BM25 ranking function
+// on the words of their text (Text, or Prompt if Text is empty).
+// It finds the exact identifiers (ticket numbers, function names...) missed by the embeddings.
+//
+// A BM25Index is safe for concurrent use.
+type BM25Index struct {
+	K1 float64 // term frequency saturation (1.2 by default)
+	B  float64 // length normalization (0.75 by default)
+
+	postings    map[string]map[string]int // term -> id -> term frequency
+	terms       map[string][]string       // id -> distinct terms
+	lengths     map[string]int            // id -> number of terms
+	totalLength int
+	mutex       sync.RWMutex
+}
+
+// NewBM25Index creates an empty BM25Index with the usual parameters (K1 = 1.2, B = 0.75).
+func NewBM25Index() *BM25Index {
+	return &BM25Index{
+		K1:       1.2,
+		B:        0.75,
+		postings: make(map[string]map[string]int),
+		terms:    make(map[string][]string),
+		lengths:  make(map[string]int),
+	}
+}
+
+// tokenize splits the text into lowercased terms.
+// The letters, the digits, '_', '-' and '.' inside a word are kept,
+// so identifiers like "JIRA-1234" or "os.ReadFile" are single terms.
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.'
+	})
+	terms := fields[:0]
+	for _, field := range fields {
+		if field = strings.Trim(field, "-."); field != "" {
+			terms = append(terms, field)
+		}
+	}
+	return terms
+}
+
+// Add indexes the record (it replaces the previous version of the record, if any).
+func (index *BM25Index) Add(record VectorRecord) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.remove(record.Id)
+	terms := tokenize(recordText(record))
+	for _, term := range terms {
+		if index.postings[term] == nil {
+			index.postings[term] = make(map[string]int)
+		}
+		index.postings[term][record.Id]++
+	}
+	index.terms[record.Id] = uniqueTerms(terms)
+	index.lengths[record.Id] = len(terms)
+	index.totalLength += len(terms)
+}
+
+// Remove removes the record from the index.
+func (index *BM25Index) Remove(id string) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	index.remove(id)
+}
+
+func (index *BM25Index) remove(id string) {
+	length, ok := index.lengths[id]
+	if !ok {
+		return
+	}
+	for _, term := range index.terms[id] {
+		delete(index.postings[term], id)
+		if len(index.postings[term]) == 0 {
+			delete(index.postings, term)
+		}
+	}
+	delete(index.terms, id)
+	delete(index.lengths, id)
+	index.totalLength -= length
+}
+
+// ScoredId is the id of a record with its score.
+type ScoredId struct {
+	Id    string
+	Score float64
+}
+
+// Search returns the ids of at most max records matching at least one term of the query,
+// the best BM25 score first.
+func (index *BM25Index) Search(query string, max int) []ScoredId {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	count := float64(len(index.lengths))
+	if count == 0 {
+		return nil
+	}
+	averageLength := float64(index.totalLength) / count
+
+	scores := map[string]float64{}
+	for _, term := range uniqueTerms(tokenize(query)) {
+		documents := index.postings[term]
+		if len(documents) == 0 {
+			continue
+		}
+		// inverse document frequency (always positive)
+		idf := math.Log(1.0 + (count-float64(len(documents))+0.5)/(float64(len(documents))+0.5))
+		for id, frequency := range documents {
+			tf := float64(frequency)
+			norm := index.K1 * (1.0 - index.B + index.B*float64(index.lengths[id])/averageLength)
+			scores[id] += idf * tf * (index.K1 + 1.0) / (tf + norm)
+		}
+	}
+
+	results := make([]ScoredId, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, ScoredId{Id: id, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Id < results[j].Id
+		}
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > max {
+		results = results[:max]
+	}
+	return results
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,185 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent is the User-Agent header sent by a Client
+// when no other value is set with WithUserAgent.
+const DefaultUserAgent = "gollama"
+
+// Client is a reusable Ollama client.
+// It owns the http.Client, the base URL of the Ollama server,
+// the default headers and the user agent used for every request.
+//
+// A Client is safe for concurrent use by multiple goroutines
+// once it has been created.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	headers    map[string]string
+	userAgent  string
+	timeout    *time.Duration // set by WithTimeout, applied once all the options have run
+}
+
+// ClientOption configures a Client created with NewClient.
+type ClientOption func(*Client)
+
+// NewClient creates a new Client for the Ollama server at baseURL
+// (ex: "http://localhost:11434").
+//
+// Parameters:
+//   - baseURL: the URL of the Ollama server.
+//   - options: functional options to customize the client.
+//
+// Returns:
+//   - *Client: the new client.
+func NewClient(baseURL string, options ...ClientOption) *Client {
+	client := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{},
+		headers:    map[string]string{},
+		userAgent:  DefaultUserAgent,
+	}
+	for _, option := range options {
+		option(client)
+	}
+	if client.timeout != nil {
+		// a copy: the http.Client given to WithHTTPClient is not modified
+		httpClient := *client.httpClient
+		httpClient.Timeout = *client.timeout
+		client.httpClient = &httpClient
+	}
+	return client
+}
+
+// WithHTTPClient sets the http.Client used to send the requests
+// (to configure the transport, the proxy, the connection pooling...).
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(client *Client) {
+		if httpClient != nil {
+			client.httpClient = httpClient
+		}
+	}
+}
+
+// WithTimeout sets the timeout of the http.Client used by the Client,
+// whatever the order of the options. With WithHTTPClient, the Client uses a copy
+// of the given http.Client with the timeout (HTTPClient returns the copy).
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(client *Client) {
+		client.timeout = &timeout
+	}
+}
+
+// WithHeader adds a header sent with every request
+// (ex: an authentication token when Ollama is behind a proxy).
+func WithHeader(name, value string) ClientOption {
+	return func(client *Client) {
+		client.headers[name] = value
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(client *Client) {
+		client.userAgent = userAgent
+	}
+}
+
+// BaseURL returns the URL of the Ollama server.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// HTTPClient returns the http.Client used by the Client.
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// newRequest creates a request to the Ollama API bound to ctx.
+// When payload is not nil, it is marshalled into JSON and used as the body.
+func (c *Client) newRequest(ctx context.Context, method, path string, payload interface{}, tokenHeaderName, tokenHeaderValue string) (*http.Request, error) {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	c.setHeaders(req, tokenHeaderName, tokenHeaderValue)
+	return req, nil
+}
+
+// setHeaders sets the user agent, the default headers of the client and the token header (if any).
+// The token header is set after the default headers,
+// so a query can override a header of the client.
+func (c *Client) setHeaders(req *http.Request, tokenHeaderName, tokenHeaderValue string) {
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	if tokenHeaderName != "" && tokenHeaderValue != "" {
+		req.Header.Set(tokenHeaderName, tokenHeaderValue)
+	}
+}
+
+// send sends the request and checks the status code of the response.
+// If the status code is not 200, the body is closed and an *APIError is returned.
+// Otherwise, the caller must close the body of the response.
+func (c *Client) send(ctx context.Context, method, path string, payload interface{}, tokenHeaderName, tokenHeaderValue string) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, path, payload, tokenHeaderName, tokenHeaderValue)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp, nil
+}
+
+// sendJSON sends the request and decodes the JSON body of the response into result
+// (the body is ignored when result is nil).
+func (c *Client) sendJSON(ctx context.Context, method, path string, payload interface{}, tokenHeaderName, tokenHeaderValue string, result interface{}) error {
+	resp, err := c.send(ctx, method, path, payload, tokenHeaderName, tokenHeaderValue)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if result == nil {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, result)
+}
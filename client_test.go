@@ -0,0 +1,109 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("😡 unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("User-Agent") != "parakeet-test" {
+			t.Errorf("😡 unexpected user agent: %s", r.Header.Get("User-Agent"))
+		}
+		if r.Header.Get("X-Team") != "devfest" {
+			t.Errorf("😡 missing default header")
+		}
+		if r.Header.Get("X-TOKEN") != "secret" {
+			t.Errorf("😡 missing token header")
+		}
+		var query Query
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			t.Errorf("😡: %v", err)
+		}
+		if query.Stream {
+			t.Errorf("😡 stream should be false")
+		}
+		w.Write([]byte(`{"model":"tinyllama","message":{"role":"assistant","content":"Hello"},"done":true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/",
+		WithUserAgent("parakeet-test"),
+		WithHeader("X-Team", "devfest"),
+		WithTimeout(5*time.Second),
+	)
+
+	if client.HTTPClient().Timeout != 5*time.Second {
+		t.Fatal("😡 timeout not set")
+	}
+
+	answer, err := client.Chat(Query{
+		Model:            "tinyllama",
+		Messages:         []Message{{Role: "user", Content: "Hi"}},
+		TokenHeaderName:  "X-TOKEN",
+		TokenHeaderValue: "secret",
+	})
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if answer.Message.Content != "Hello" {
+		t.Fatal("😡 unexpected answer:", answer.Message.Content)
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	httpClient := &http.Client{Timeout: time.Minute}
+	client := NewClient("http://localhost:11434", WithHTTPClient(httpClient))
+	if client.HTTPClient() != httpClient {
+		t.Fatal("😡 http client not set")
+	}
+	if client.BaseURL() != "http://localhost:11434" {
+		t.Fatal("😡 unexpected base url:", client.BaseURL())
+	}
+
+	// the timeout does not depend on the order of the options
+	for _, options := range [][]ClientOption{
+		{WithTimeout(5 * time.Second), WithHTTPClient(httpClient)},
+		{WithHTTPClient(httpClient), WithTimeout(5 * time.Second)},
+	} {
+		client := NewClient("http://localhost:11434", options...)
+		if client.HTTPClient().Timeout != 5*time.Second || httpClient.Timeout != time.Minute {
+			t.Fatal("😡 unexpected timeout:", client.HTTPClient().Timeout, httpClient.Timeout)
+		}
+	}
+}
+
+func TestChatStreamContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"tinyllama","message":{"role":"assistant","content":"Hel"},"done":false}` + "\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, err := NewClient(server.URL).ChatStreamContext(ctx, Query{Model: "tinyllama"},
+		func(answer Answer) error {
+			// stop the generation after the first chunk
+			cancel()
+			return nil
+		})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("😡 expected context.Canceled, got:", err)
+	}
+}
@@ -0,0 +1,246 @@
+package gollama
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// === Collections ===
+
+var (
+	// ErrCollectionNotFound is returned (wrapped) when a collection does not exist.
+	ErrCollectionNotFound = errors.New("Error: collection not found")
+	// ErrCollectionExists is returned (wrapped) when creating a collection that already exists.
+	ErrCollectionExists = errors.New("Error: collection already exists")
+)
+
+// CollectionInfo describes a collection: the embedding model and the dimension
+// of the embeddings of its records.
+type CollectionInfo struct {
+	Name      string `json:"name"`
+	Model     string `json:"model"`     // ex: "all-minilm"
+	Dimension int    `json:"dimension"` // ex: 384
+}
+
+// Collection is a named VectorStore of a Collections.
+// The records and the queries with another embedding model or dimension
+// than the ones of the collection are rejected with a *ModelError or a *DimensionError
+// (a zero Dimension or an empty Model is not checked).
+type Collection struct {
+	CollectionInfo
+	VectorStore
+}
+
+var _ MetricVectorStore = (*Collection)(nil)
+
+func (collection *Collection) check(record VectorRecord) error {
+	spec := embeddingSpec{model: collection.Model, dimension: collection.Dimension}
+	return spec.check(record)
+}
+
+func (collection *Collection) Save(vectorRecord VectorRecord) (VectorRecord, error) {
+	if err := collection.check(vectorRecord); err != nil {
+		return VectorRecord{}, err
+	}
+	return collection.VectorStore.Save(vectorRecord)
+}
+
+func (collection *Collection) SearchSimilarities(embeddingFromQuestion VectorRecord, limit float64) ([]VectorRecord, error) {
+	if err := collection.check(embeddingFromQuestion); err != nil {
+		return nil, err
+	}
+	return collection.VectorStore.SearchSimilarities(embeddingFromQuestion, limit)
+}
+
+func (collection *Collection) SearchTopNSimilarities(embeddingFromQuestion VectorRecord, limit float64, max int) ([]VectorRecord, error) {
+	if err := collection.check(embeddingFromQuestion); err != nil {
+		return nil, err
+	}
+	return collection.VectorStore.SearchTopNSimilarities(embeddingFromQuestion, limit, max)
+}
+
+// SearchSimilaritiesWithMetric searches the collection with the metric
+// (see MemoryVectorStore.SearchSimilaritiesWithMetric).
+// It returns an error if the store of the collection is not a MetricVectorStore.
+func (collection *Collection) SearchSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, metric Metric) ([]VectorRecord, error) {
+	store, err := collection.metricStore(embeddingFromQuestion, metric)
+	if err != nil {
+		return nil, err
+	}
+	return store.SearchSimilaritiesWithMetric(embeddingFromQuestion, limit, metric)
+}
+
+// SearchTopNSimilaritiesWithMetric is like SearchSimilaritiesWithMetric,
+// but returns at most max records, the most similar first.
+func (collection *Collection) SearchTopNSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, max int, metric Metric) ([]VectorRecord, error) {
+	store, err := collection.metricStore(embeddingFromQuestion, metric)
+	if err != nil {
+		return nil, err
+	}
+	return store.SearchTopNSimilaritiesWithMetric(embeddingFromQuestion, limit, max, metric)
+}
+
+func (collection *Collection) metricStore(embeddingFromQuestion VectorRecord, metric Metric) (MetricVectorStore, error) {
+	store, ok := collection.VectorStore.(MetricVectorStore)
+	if !ok {
+		return nil, fmt.Errorf("Error: the metrics are not supported by the store of the collection %q (%T)", collection.Name, collection.VectorStore)
+	}
+	if metric.comparesEmbeddings() {
+		if err := collection.check(embeddingFromQuestion); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// CollectionRecord is a record found by a search over several collections.
+type CollectionRecord struct {
+	Collection string `json:"collection"`
+	VectorRecord
+}
+
+// Collections is a set of named collections (ex: one collection per tenant, or per product documentation),
+// each collection being a separate VectorStore.
+//
+// Collections is safe for concurrent use.
+type Collections struct {
+	newStore    func(name string) (VectorStore, error)
+	collections map[string]*Collection
+	mutex       sync.RWMutex
+}
+
+// NewCollections creates an empty set of collections.
+// newStore creates the VectorStore of a new collection;
+// if it is nil, the collections are MemoryVectorStores.
+func NewCollections(newStore func(name string) (VectorStore, error)) *Collections {
+	if newStore == nil {
+		newStore = func(name string) (VectorStore, error) {
+			return NewMemoryVectorStore(), nil
+		}
+	}
+	return &Collections{
+		newStore:    newStore,
+		collections: make(map[string]*Collection),
+	}
+}
+
+// Create creates the collection name for the embeddings of the model with the dimension.
+// It returns an error wrapping ErrCollectionExists if the collection already exists.
+func (c *Collections) Create(name, model string, dimension int) (*Collection, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.collections[name]; ok {
+		return nil, fmt.Errorf("%w: %q", ErrCollectionExists, name)
+	}
+	store, err := c.newStore(name)
+	if err != nil {
+		return nil, err
+	}
+	collection := &Collection{
+		CollectionInfo: CollectionInfo{Name: name, Model: model, Dimension: dimension},
+		VectorStore:    store,
+	}
+	c.collections[name] = collection
+	return collection, nil
+}
+
+// Get returns the collection name.
+// It returns an error wrapping ErrCollectionNotFound if the collection does not exist.
+func (c *Collections) Get(name string) (*Collection, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	collection, ok := c.collections[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrCollectionNotFound, name)
+	}
+	return collection, nil
+}
+
+// Drop removes the collection name and its records: the store of the collection
+// is removed if it has a Remove method (ex: FileVectorStore deletes its log file),
+// else it is closed if it is an io.Closer.
+// It returns an error wrapping ErrCollectionNotFound if the collection does not exist.
+func (c *Collections) Drop(name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	collection, ok := c.collections[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrCollectionNotFound, name)
+	}
+	delete(c.collections, name)
+	switch store := collection.VectorStore.(type) {
+	case interface{ Remove() error }:
+		return store.Remove()
+	case io.Closer:
+		return store.Close()
+	}
+	return nil
+}
+
+// List returns the description of the collections, sorted by name.
+func (c *Collections) List() []CollectionInfo {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	infos := make([]CollectionInfo, 0, len(c.collections))
+	for _, collection := range c.collections {
+		infos = append(infos, collection.CollectionInfo)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// SearchTopNSimilarities searches the max most similar records of the collections names
+// with a cosine similarity greater than or equal to limit, the most similar first.
+// If names is empty, all the collections with the embedding model and the dimension
+// of the question are searched (the other ones are skipped).
+// It returns an error wrapping ErrCollectionNotFound if a collection does not exist,
+// and a *ModelError or a *DimensionError if a named collection does not match the question.
+func (c *Collections) SearchTopNSimilarities(names []string, embeddingFromQuestion VectorRecord, limit float64, max int) ([]CollectionRecord, error) {
+	var collections []*Collection
+	if len(names) == 0 {
+		c.mutex.RLock()
+		for _, collection := range c.collections {
+			collections = append(collections, collection)
+		}
+		c.mutex.RUnlock()
+	}
+	for _, name := range names {
+		collection, err := c.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+
+	var results []CollectionRecord
+	for _, collection := range collections {
+		records, err := collection.SearchTopNSimilarities(embeddingFromQuestion, limit, max)
+		var modelError *ModelError
+		var dimensionError *DimensionError
+		if len(names) == 0 && (errors.As(err, &modelError) || errors.As(err, &dimensionError)) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			results = append(results, CollectionRecord{Collection: collection.Name, VectorRecord: record})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].CosineDistance == results[j].CosineDistance {
+			return results[i].Collection < results[j].Collection
+		}
+		return results[i].CosineDistance > results[j].CosineDistance
+	})
+	if len(results) > max {
+		results = results[:max]
+	}
+	return results, nil
+}
@@ -0,0 +1,116 @@
+package gollama
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCollections(t *testing.T) {
+	collections := NewCollections(nil)
+
+	tenant1, err := collections.Create("tenant-1", "all-minilm", 2)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	tenant2, _ := collections.Create("tenant-2", "all-minilm", 2)
+	collections.Create("docs", "nomic-embed-text", 2)
+
+	if _, err := collections.Create("tenant-1", "all-minilm", 2); !errors.Is(err, ErrCollectionExists) {
+		t.Fatal("😡 expected ErrCollectionExists:", err)
+	}
+
+	tenant1.Save(VectorRecord{Id: "kirk", Embedding: []float64{1.0, 0.2}})
+	tenant1.Save(VectorRecord{Id: "spock", Embedding: []float64{0.0, 1.0}})
+	tenant2.Save(VectorRecord{Id: "kirk", Embedding: []float64{1.0, 0.0}})
+	tenant2.Save(VectorRecord{Id: "picard", Embedding: []float64{0.9, 0.1}})
+
+	list := collections.List()
+	if len(list) != 3 || list[0].Name != "docs" || list[0].Model != "nomic-embed-text" || list[1].Name != "tenant-1" || list[1].Dimension != 2 {
+		t.Fatal("😡 unexpected collections:", list)
+	}
+
+	question := VectorRecord{Embedding: []float64{1.0, 0.0}}
+
+	// one collection
+	results, err := collections.SearchTopNSimilarities([]string{"tenant-1"}, question, 0.5, 3)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(results) != 1 || results[0].Collection != "tenant-1" || results[0].Id != "kirk" {
+		t.Fatal("😡 unexpected results:", results)
+	}
+
+	// several collections
+	results, _ = collections.SearchTopNSimilarities([]string{"tenant-1", "tenant-2"}, question, 0.5, 2)
+	if len(results) != 2 || results[0].Collection != "tenant-2" || results[0].Id != "kirk" || results[1].Id != "picard" {
+		t.Fatal("😡 unexpected results:", results)
+	}
+
+	// all the collections
+	results, _ = collections.SearchTopNSimilarities(nil, question, 0.5, 10)
+	if len(results) != 3 {
+		t.Fatal("😡 unexpected results:", results)
+	}
+
+	// all the collections of the model of the question, the other ones are skipped
+	question.Model = "all-minilm"
+	results, err = collections.SearchTopNSimilarities(nil, question, 0.5, 10)
+	if err != nil || len(results) != 3 {
+		t.Fatal("😡 unexpected results:", results, err)
+	}
+	var modelError *ModelError
+	if _, err := collections.SearchTopNSimilarities([]string{"tenant-1", "docs"}, question, 0.5, 10); !errors.As(err, &modelError) {
+		t.Fatal("😡 expected a *ModelError:", err)
+	}
+	question.Model = ""
+
+	if err := collections.Drop("tenant-2"); err != nil {
+		t.Fatal("😡:", err)
+	}
+	if _, err := collections.Get("tenant-2"); !errors.Is(err, ErrCollectionNotFound) {
+		t.Fatal("😡 expected ErrCollectionNotFound:", err)
+	}
+	if err := collections.Drop("tenant-2"); !errors.Is(err, ErrCollectionNotFound) {
+		t.Fatal("😡 expected ErrCollectionNotFound:", err)
+	}
+	if _, err := collections.SearchTopNSimilarities([]string{"tenant-2"}, question, 0.5, 2); !errors.Is(err, ErrCollectionNotFound) {
+		t.Fatal("😡 expected ErrCollectionNotFound:", err)
+	}
+}
+
+func TestCollectionsWithFileVectorStores(t *testing.T) {
+	directory := t.TempDir()
+	var stores []*FileVectorStore
+	collections := NewCollections(func(name string) (VectorStore, error) {
+		store, err := OpenFileVectorStore(filepath.Join(directory, name+".jsonl"))
+		stores = append(stores, store)
+		return store, err
+	})
+
+	collection, err := collections.Create("docs", "all-minilm", 2)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	collection.Save(VectorRecord{Id: "kirk", Embedding: []float64{1.0, 0.2}})
+
+	// the store is closed when the collection is dropped
+	if err := collections.Drop("docs"); err != nil {
+		t.Fatal("😡:", err)
+	}
+	if _, err := stores[0].Save(VectorRecord{Id: "spock"}); err == nil {
+		t.Fatal("😡 the store should be closed")
+	}
+
+	// the records of the dropped collection are deleted
+	collection, err = collections.Create("docs", "nomic-embed-text", 3)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if count, _ := collection.Count(); count != 0 {
+		t.Fatal("😡 the records of the dropped collection are back:", count)
+	}
+	if _, err := collection.Save(VectorRecord{Id: "kirk", Model: "nomic-embed-text", Embedding: []float64{1.0, 0.2, 0.0}}); err != nil {
+		t.Fatal("😡:", err)
+	}
+}
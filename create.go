@@ -0,0 +1,218 @@
+package gollama
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// === Models creation ===
+
+// ModelfileParameter is a PARAMETER instruction of a Modelfile (ex: temperature 0.5).
+type ModelfileParameter struct {
+	Name  string
+	Value interface{}
+}
+
+// Modelfile is a structured builder for the Modelfile of a model.
+// https://github.com/ollama/ollama/blob/main/docs/modelfile.md
+//
+//	modelfile := gollama.Modelfile{
+//		From:   "qwen2.5:0.5b",
+//		System: "You are a Star Trek expert.",
+//	}
+//	modelfile.AddParameter("temperature", 0.0)
+type Modelfile struct {
+	From       string // base model or path to a GGUF file/blob
+	Adapter    string // path to a (Q)LoRA adapter
+	Template   string
+	System     string
+	License    string
+	Parameters []ModelfileParameter
+	Messages   []Message // conversation history (MESSAGE instructions)
+}
+
+// AddParameter adds a PARAMETER instruction.
+// A parameter can be added several times (ex: stop).
+func (m *Modelfile) AddParameter(name string, value interface{}) *Modelfile {
+	m.Parameters = append(m.Parameters, ModelfileParameter{Name: name, Value: value})
+	return m
+}
+
+// String returns the content of the Modelfile.
+func (m Modelfile) String() string {
+	var builder strings.Builder
+	builder.WriteString("FROM " + m.From + "\n")
+	if m.Adapter != "" {
+		builder.WriteString("ADAPTER " + m.Adapter + "\n")
+	}
+	for _, parameter := range m.Parameters {
+		builder.WriteString(fmt.Sprintf("PARAMETER %s %s\n", parameter.Name, modelfileValue(fmt.Sprint(parameter.Value))))
+	}
+	if m.Template != "" {
+		builder.WriteString("TEMPLATE " + modelfileValue(m.Template) + "\n")
+	}
+	if m.System != "" {
+		builder.WriteString("SYSTEM " + modelfileValue(m.System) + "\n")
+	}
+	if m.License != "" {
+		builder.WriteString("LICENSE " + modelfileValue(m.License) + "\n")
+	}
+	for _, message := range m.Messages {
+		builder.WriteString("MESSAGE " + message.Role + " " + modelfileValue(message.Content) + "\n")
+	}
+	return builder.String()
+}
+
+// modelfileValue quotes the value when it contains spaces, quotes or newlines.
+func modelfileValue(value string) string {
+	if strings.Contains(value, "\n") || strings.Contains(value, `"`) {
+		return `"""` + value + `"""`
+	}
+	if strings.ContainsAny(value, " \t") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// CreateModelQuery is the request of the /api/create endpoint.
+type CreateModelQuery struct {
+	Model     string `json:"model"`
+	Modelfile string `json:"modelfile"`          // use Modelfile.String() to build it
+	Quantize  string `json:"quantize,omitempty"` // ex: "q4_K_M"
+	Stream    bool   `json:"stream"`
+}
+
+// PushModelQuery is the request of the /api/push endpoint.
+type PushModelQuery struct {
+	Model    string `json:"model"` // <namespace>/<model>:<tag>
+	Insecure bool   `json:"insecure,omitempty"`
+	Stream   bool   `json:"stream"`
+}
+
+// CreateModel creates a model from a Modelfile
+// and calls onProgress (if not nil) for every status event.
+// The files referenced by the Modelfile must be uploaded first with PushBlob.
+func (c *Client) CreateModel(ctx context.Context, query CreateModelQuery, onProgress func(Progress) error) error {
+	query.Stream = true
+	return c.streamProgress(ctx, "/api/create", query, onProgress)
+}
+
+// PushModel uploads a model to a registry
+// and calls onProgress (if not nil) for every progress event.
+func (c *Client) PushModel(ctx context.Context, query PushModelQuery, onProgress func(Progress) error) error {
+	query.Stream = true
+	return c.streamProgress(ctx, "/api/push", query, onProgress)
+}
+
+func (c *Client) streamProgress(ctx context.Context, path string, query interface{}, onProgress func(Progress) error) error {
+	resp, err := c.send(ctx, http.MethodPost, path, query, "", "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return decodeStream(ctx, resp.Body, func(progress Progress) error {
+		if onProgress == nil {
+			return nil
+		}
+		return onProgress(progress)
+	})
+}
+
+// FileDigest returns the sha256 digest of a file ("sha256:<hex>"),
+// as expected by the /api/blobs endpoint.
+func FileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// HasBlob reports whether the blob with this digest exists on the Ollama server.
+func (c *Client) HasBlob(ctx context.Context, digest string) (bool, error) {
+	resp, err := c.send(ctx, http.MethodHead, "/api/blobs/"+digest, nil, "", "")
+	if err != nil {
+		var apiError *APIError
+		if errors.As(err, &apiError) && apiError.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// PushBlob uploads a local file (ex: a GGUF file or an adapter) to the Ollama server
+// and returns its digest, to be used in the FROM or ADAPTER instruction of a Modelfile
+// ("@sha256:..."). The file is not uploaded again if the blob already exists.
+func (c *Client) PushBlob(ctx context.Context, path string) (string, error) {
+	digest, err := FileDigest(path)
+	if err != nil {
+		return "", err
+	}
+
+	found, err := c.HasBlob(ctx, digest)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		return digest, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/blobs/"+digest, file)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	c.setHeaders(req, "", "")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+	return digest, nil
+}
+
+func CreateModel(ctx context.Context, url string, query CreateModelQuery, onProgress func(Progress) error) error {
+	return NewClient(url).CreateModel(ctx, query, onProgress)
+}
+
+func PushModel(ctx context.Context, url string, query PushModelQuery, onProgress func(Progress) error) error {
+	return NewClient(url).PushModel(ctx, query, onProgress)
+}
+
+func HasBlob(ctx context.Context, url string, digest string) (bool, error) {
+	return NewClient(url).HasBlob(ctx, digest)
+}
+
+func PushBlob(ctx context.Context, url string, path string) (string, error) {
+	return NewClient(url).PushBlob(ctx, path)
+}
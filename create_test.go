@@ -0,0 +1,95 @@
+package gollama
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModelfile(t *testing.T) {
+	modelfile := Modelfile{
+		From:     "qwen2.5:0.5b",
+		System:   "You are a Star Trek expert.",
+		Messages: []Message{{Role: "user", Content: "Who is Kirk?"}},
+	}
+	modelfile.AddParameter("temperature", 0.5).AddParameter("stop", "<|im_end|>")
+
+	expected := "FROM qwen2.5:0.5b\n" +
+		"PARAMETER temperature 0.5\n" +
+		"PARAMETER stop <|im_end|>\n" +
+		"SYSTEM \"You are a Star Trek expert.\"\n" +
+		"MESSAGE user \"Who is Kirk?\"\n"
+
+	if modelfile.String() != expected {
+		t.Fatal("😡 unexpected Modelfile:\n", modelfile.String())
+	}
+}
+
+func TestCreateModelWithBlob(t *testing.T) {
+	blobs := map[string][]byte{}
+	var created CreateModelQuery
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead:
+			if _, ok := blobs[r.URL.Path[len("/api/blobs/"):]]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPost && r.URL.Path == "/api/create":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.Write([]byte(`{"status":"reading model metadata"}` + "\n"))
+			w.Write([]byte(`{"status":"success"}` + "\n"))
+		case r.Method == http.MethodPost:
+			content, _ := io.ReadAll(r.Body)
+			blobs[r.URL.Path[len("/api/blobs/"):]] = content
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "adapter.gguf")
+	if err := os.WriteFile(path, []byte("GGUF"), 0644); err != nil {
+		t.Fatal("😡:", err)
+	}
+
+	ctx := context.Background()
+	client := NewClient(server.URL)
+
+	digest, err := client.PushBlob(ctx, path)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	sum := sha256.Sum256([]byte("GGUF"))
+	if digest != "sha256:"+hex.EncodeToString(sum[:]) {
+		t.Fatal("😡 unexpected digest:", digest)
+	}
+	if string(blobs[digest]) != "GGUF" {
+		t.Fatal("😡 the blob has not been uploaded")
+	}
+	if found, err := client.HasBlob(ctx, digest); err != nil || !found {
+		t.Fatal("😡 the blob should exist:", err)
+	}
+
+	modelfile := Modelfile{From: "tinyllama", Adapter: "@" + digest}
+	var statuses []string
+	err = client.CreateModel(ctx, CreateModelQuery{Model: "star-trek", Modelfile: modelfile.String()}, func(progress Progress) error {
+		statuses = append(statuses, progress.Status)
+		return nil
+	})
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(statuses) != 2 || statuses[1] != "success" {
+		t.Fatal("😡 unexpected statuses:", statuses)
+	}
+	if created.Model != "star-trek" || created.Modelfile != "FROM tinyllama\nADAPTER @"+digest+"\n" {
+		t.Fatal("😡 unexpected create query:", created)
+	}
+}
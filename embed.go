@@ -0,0 +1,137 @@
+package gollama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// === Batch embeddings ===
+
+// DefaultEmbeddingsBatchSize is the maximum number of inputs
+// sent in one request to /api/embed by CreateEmbeddings.
+const DefaultEmbeddingsBatchSize = 256
+
+// Query4Embeddings is the request of the /api/embed endpoint.
+// https://github.com/ollama/ollama/blob/main/docs/api.md#generate-embeddings
+type Query4Embeddings struct {
+	Model     string   `json:"model"`
+	Input     []string `json:"input"`
+	Truncate  *bool    `json:"truncate,omitempty"`   // truncate the inputs that exceed the context length (true by default)
+	KeepAlive string   `json:"keep_alive,omitempty"` // ex: "5m"
+	Options   *Options `json:"options,omitempty"`
+}
+
+// EmbeddingsResponse is the response of the /api/embed endpoint.
+type EmbeddingsResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float64 `json:"embeddings"`
+
+	Metrics
+}
+
+type embeddingsConfig struct {
+	query     Query4Embeddings
+	batchSize int
+	idFunc    func(index int, input string) string
+}
+
+// EmbeddingsOption configures a CreateEmbeddings call.
+type EmbeddingsOption func(*embeddingsConfig)
+
+// WithTruncate sets whether Ollama truncates the inputs that exceed the context length
+// (if false, an error is returned for these inputs).
+func WithTruncate(truncate bool) EmbeddingsOption {
+	return func(config *embeddingsConfig) {
+		config.query.Truncate = &truncate
+	}
+}
+
+// WithKeepAlive sets how long the model stays loaded into memory after the request (ex: "5m").
+func WithKeepAlive(keepAlive string) EmbeddingsOption {
+	return func(config *embeddingsConfig) {
+		config.query.KeepAlive = keepAlive
+	}
+}
+
+// WithEmbeddingsOptions sets the model options (ex: NumCtx).
+func WithEmbeddingsOptions(options Options) EmbeddingsOption {
+	return func(config *embeddingsConfig) {
+		config.query.Options = &options
+	}
+}
+
+// WithBatchSize sets the maximum number of inputs sent in one request
+// (DefaultEmbeddingsBatchSize by default).
+func WithBatchSize(batchSize int) EmbeddingsOption {
+	return func(config *embeddingsConfig) {
+		if batchSize > 0 {
+			config.batchSize = batchSize
+		}
+	}
+}
+
+// WithIdFunc sets the function used to compute the id of every VectorRecord
+// from the index and the text of the input (the index is used by default).
+func WithIdFunc(idFunc func(index int, input string) string) EmbeddingsOption {
+	return func(config *embeddingsConfig) {
+		config.idFunc = idFunc
+	}
+}
+
+// CreateEmbeddings creates the embeddings of all the inputs with the /api/embed endpoint.
+// The inputs are sent by batches (see WithBatchSize).
+//
+// Parameters:
+//   - ctx: the requests are canceled when ctx is done.
+//   - model: the embedding model (ex: "all-minilm").
+//   - inputs: the texts to embed.
+//   - options: WithTruncate, WithKeepAlive, WithBatchSize, WithIdFunc...
+//
+// Returns:
+//   - []VectorRecord: one vector record per input, in the same order.
+//   - error: an error if any occurred.
+func (c *Client) CreateEmbeddings(ctx context.Context, model string, inputs []string, options ...EmbeddingsOption) ([]VectorRecord, error) {
+	config := embeddingsConfig{
+		query:     Query4Embeddings{Model: model},
+		batchSize: DefaultEmbeddingsBatchSize,
+		idFunc: func(index int, input string) string {
+			return strconv.Itoa(index)
+		},
+	}
+	for _, option := range options {
+		option(&config)
+	}
+
+	records := make([]VectorRecord, 0, len(inputs))
+	for start := 0; start < len(inputs); start += config.batchSize {
+		end := min(start+config.batchSize, len(inputs))
+
+		query := config.query
+		query.Input = inputs[start:end]
+
+		var response EmbeddingsResponse
+		err := c.sendJSON(ctx, http.MethodPost, "/api/embed", query, "", "", &response)
+		if err != nil {
+			return nil, err
+		}
+		if len(response.Embeddings) != len(query.Input) {
+			return nil, fmt.Errorf("Error: expected %d embeddings, got %d", len(query.Input), len(response.Embeddings))
+		}
+
+		for idx, embedding := range response.Embeddings {
+			records = append(records, VectorRecord{
+				Id:        config.idFunc(start+idx, query.Input[idx]),
+				Prompt:    query.Input[idx],
+				Embedding: embedding,
+				Model:     model,
+			})
+		}
+	}
+	return records, nil
+}
+
+func CreateEmbeddings(ctx context.Context, url string, model string, inputs []string, options ...EmbeddingsOption) ([]VectorRecord, error) {
+	return NewClient(url).CreateEmbeddings(ctx, model, inputs, options...)
+}
@@ -0,0 +1,63 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newEmbedServer returns a fake /api/embed endpoint:
+// the embedding of an input is [length of the input, 1]
+func newEmbedServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("😡 unexpected path: %s", r.URL.Path)
+		}
+		var query Query4Embeddings
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			t.Errorf("😡: %v", err)
+		}
+		if requests != nil {
+			*requests++
+		}
+		response := EmbeddingsResponse{Model: query.Model}
+		for _, input := range query.Input {
+			response.Embeddings = append(response.Embeddings, []float64{float64(len(input)), 1.0})
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestCreateEmbeddings(t *testing.T) {
+	requests := 0
+	server := newEmbedServer(t, &requests)
+	defer server.Close()
+
+	inputs := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+
+	records, err := CreateEmbeddings(context.Background(), server.URL, "all-minilm", inputs,
+		WithBatchSize(2),
+		WithTruncate(false),
+		WithKeepAlive("10m"),
+		WithIdFunc(func(index int, input string) string {
+			return "doc-" + strings.Repeat("#", index)
+		}),
+	)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if requests != 3 {
+		t.Fatal("😡 expected 3 batches, got:", requests)
+	}
+	if len(records) != len(inputs) {
+		t.Fatal("😡 unexpected number of records:", len(records))
+	}
+	for idx, record := range records {
+		if record.Prompt != inputs[idx] || record.Embedding[0] != float64(len(inputs[idx])) || record.Id != "doc-"+strings.Repeat("#", idx) {
+			t.Fatal("😡 unexpected record:", record)
+		}
+	}
+}
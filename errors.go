@@ -0,0 +1,111 @@
+package gollama
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// APIError is returned when the Ollama API answers with a non-200 status code.
+// Use errors.As to get the details:
+//
+//	var apiErr *gollama.APIError
+//	if errors.As(err, &apiErr) {
+//		fmt.Println(apiErr.StatusCode, apiErr.Message)
+//	}
+type APIError struct {
+	StatusCode int    // ex: 404
+	Status     string // ex: "404 Not Found"
+	Message    string // the "error" field of the body returned by Ollama (ex: "model 'x' not found, try pulling it first")
+	Path       string // the path of the request (ex: "/api/chat")
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return "Error: status code: " + e.Status
+	}
+	return "Error: status code: " + e.Status + ": " + e.Message
+}
+
+// newAPIError creates an APIError from a non-200 response.
+// It decodes the {"error": "..."} body sent by Ollama;
+// if the body is not JSON, the raw body is used as the message.
+func newAPIError(resp *http.Response) *APIError {
+	apiError := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		apiError.Path = resp.Request.URL.Path
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return apiError
+	}
+	var errorBody struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &errorBody) == nil && errorBody.Error != "" {
+		apiError.Message = errorBody.Error
+	} else {
+		apiError.Message = strings.TrimSpace(string(body))
+	}
+	return apiError
+}
+
+// IsModelNotFound reports whether err is an APIError
+// telling that the model does not exist (and needs to be pulled).
+func IsModelNotFound(err error) bool {
+	var apiError *APIError
+	if !errors.As(err, &apiError) {
+		return false
+	}
+	return apiError.StatusCode == http.StatusNotFound && strings.Contains(strings.ToLower(apiError.Message), "not found")
+}
+
+// IsUnauthorized reports whether err is an APIError with a 401 Unauthorized status code
+// (ex: wrong or missing token when Ollama is behind a proxy).
+func IsUnauthorized(err error) bool {
+	var apiError *APIError
+	if !errors.As(err, &apiError) {
+		return false
+	}
+	return apiError.StatusCode == http.StatusUnauthorized
+}
+
+// DimensionError is returned when the dimension of an embedding
+// differs from the dimension of the other embeddings (ex: a 768-dim embedding
+// saved in a vector store of 384-dim embeddings, or a query with the wrong dimension).
+type DimensionError struct {
+	Id       string // the id of the record (empty for a query)
+	Expected int
+	Actual   int
+}
+
+func (e *DimensionError) Error() string {
+	message := "Error: dimension mismatch: expected " + strconv.Itoa(e.Expected) + ", got " + strconv.Itoa(e.Actual)
+	if e.Id != "" {
+		message += " (record " + strconv.Quote(e.Id) + ")"
+	}
+	return message
+}
+
+// ModelError is returned when the embedding model of a record or of a query
+// differs from the embedding model of the vector store.
+type ModelError struct {
+	Id       string // the id of the record (empty for a query)
+	Expected string
+	Actual   string
+}
+
+func (e *ModelError) Error() string {
+	message := "Error: embedding model mismatch: expected " + strconv.Quote(e.Expected) + ", got " + strconv.Quote(e.Actual)
+	if e.Id != "" {
+		message += " (record " + strconv.Quote(e.Id) + ")"
+	}
+	return message
+}
@@ -0,0 +1,51 @@
+package gollama
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-TOKEN") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Unauthorized"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model 'tinyllama' not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	query := Query{Model: "tinyllama", TokenHeaderName: "X-TOKEN", TokenHeaderValue: "secret"}
+
+	_, err := Chat(server.URL, query)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatal("😡 expected an APIError, got:", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Path != "/api/chat" {
+		t.Fatal("😡 unexpected error:", apiErr)
+	}
+	if apiErr.Message != "model 'tinyllama' not found, try pulling it first" {
+		t.Fatal("😡 unexpected message:", apiErr.Message)
+	}
+	if !IsModelNotFound(err) || IsUnauthorized(err) {
+		t.Fatal("😡 expected a model not found error")
+	}
+
+	_, err = ChatStream(server.URL, query, func(answer Answer) error { return nil })
+	if !IsModelNotFound(err) {
+		t.Fatal("😡 expected a model not found error, got:", err)
+	}
+
+	_, err = CreateEmbedding(server.URL, Query4Embedding{Model: "all-minilm"}, "000")
+	if !IsUnauthorized(err) || IsModelNotFound(err) {
+		t.Fatal("😡 expected an unauthorized error, got:", err)
+	}
+	if err.Error() != "Error: status code: 401 Unauthorized: Unauthorized" {
+		t.Fatal("😡 unexpected message:", err)
+	}
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"strconv"
 
 	"github.com/parakeet-nest/gollama"
 
@@ -43,26 +43,19 @@ func main() {
 	embeddingsModel := "qwen2:1.5b"
 
 
-	store := gollama.MemoryVectorStore{
-		Records: make(map[string]gollama.VectorRecord),
-	}
+	store := gollama.NewMemoryVectorStore()
 
 	// Create embeddings from documents and save them in the store
-	for idx, doc := range docs {
-		fmt.Println("Creating embedding from document ", idx)
-		embedding, err := gollama.CreateEmbedding(
-			ollamaUrl,
-			gollama.Query4Embedding{
-				Model:  embeddingsModel,
-				Prompt: doc,
-			},
-			strconv.Itoa(idx),
-		)
-		if err != nil {
-			fmt.Println("😡:", err)
-		} else {
-			store.Save(embedding)
-		}
+	_, err := gollama.EmbedAll(context.Background(), ollamaUrl, docs, gollama.EmbedAllOptions{
+		Model:   embeddingsModel,
+		Workers: 2,
+		OnProgress: func(done, total int) {
+			fmt.Println("Creating embedding from document ", done, "/", total)
+		},
+		Store: store,
+	})
+	if err != nil {
+		log.Fatalln("😡:", err)
 	}
 
 	userContent := `Who is Philippe Charrière and what spaceship does he work on?`
@@ -0,0 +1,222 @@
+package gollama
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// === Export / Import ===
+
+// sortedIds returns the ids of the records sorted (the exports are deterministic).
+// The caller must hold the lock of the store.
+func (mvs *MemoryVectorStore) sortedIds() []string {
+	ids := make([]string, 0, len(mvs.Records))
+	for id := range mvs.Records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ExportJSONL writes the records to w, one JSON VectorRecord per line, sorted by id.
+// The records are encoded one by one (the store is not copied),
+// the writes to the store wait until the end of the export.
+func (mvs *MemoryVectorStore) ExportJSONL(w io.Writer) error {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	for _, id := range mvs.sortedIds() {
+		if err := encoder.Encode(mvs.Records[id]); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// ImportJSONL reads the records written by ExportJSONL (one JSON VectorRecord per line)
+// and saves them one by one, and returns the number of imported records.
+// A line that cannot be decoded stops the import with a *DecodeError.
+// The Version of the imported records is set by Save.
+func (mvs *MemoryVectorStore) ImportJSONL(r io.Reader) (int, error) {
+	imported := 0
+	err := decodeStream(context.Background(), r, func(record VectorRecord) error {
+		if _, err := mvs.Save(record); err != nil {
+			return err
+		}
+		imported++
+		return nil
+	})
+	return imported, err
+}
+
+// ExportNPY writes the embeddings to matrix as a NumPy .npy file
+// (a float64 matrix of shape (number of records, dimension), one row per record, sorted by id)
+// and the other fields of the records to metadata, one JSON VectorRecord per line
+// without the embedding (the line i describes the row i):
+//
+//	embeddings = numpy.load("embeddings.npy")
+//	metadata = pandas.read_json("metadata.jsonl", lines=True)
+//
+// The rows are written one by one (the store is not copied),
+// the writes to the store wait until the end of the export.
+func (mvs *MemoryVectorStore) ExportNPY(matrix io.Writer, metadata io.Writer) error {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+
+	ids := mvs.sortedIds()
+	dimension := 0
+	if len(ids) > 0 {
+		dimension = len(mvs.Records[ids[0]].Embedding)
+	}
+
+	matrixWriter := bufio.NewWriter(matrix)
+	if err := writeNPYHeader(matrixWriter, "<f8", len(ids), dimension); err != nil {
+		return err
+	}
+	metadataWriter := bufio.NewWriter(metadata)
+	encoder := json.NewEncoder(metadataWriter)
+
+	row := make([]byte, 8*dimension)
+	for _, id := range ids {
+		record := mvs.Records[id]
+		if len(record.Embedding) != dimension {
+			return &DimensionError{Id: id, Expected: dimension, Actual: len(record.Embedding)}
+		}
+		for i, value := range record.Embedding {
+			binary.LittleEndian.PutUint64(row[8*i:], math.Float64bits(value))
+		}
+		if _, err := matrixWriter.Write(row); err != nil {
+			return err
+		}
+		record.Embedding = nil
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	if err := matrixWriter.Flush(); err != nil {
+		return err
+	}
+	return metadataWriter.Flush()
+}
+
+// ImportNPY reads the files written by ExportNPY (or by numpy.save,
+// with a 2-D float64 or float32 matrix) and saves the records one by one,
+// and returns the number of imported records.
+// The line i of metadata is the record of the row i of matrix.
+// The Version of the imported records is set by Save.
+func (mvs *MemoryVectorStore) ImportNPY(matrix io.Reader, metadata io.Reader) (int, error) {
+	matrixReader := bufio.NewReader(matrix)
+	descr, rows, dimension, err := readNPYHeader(matrixReader)
+	if err != nil {
+		return 0, err
+	}
+	size := 8
+	if descr == "<f4" {
+		size = 4
+	}
+	row := make([]byte, size*dimension)
+
+	imported := 0
+	err = decodeStream(context.Background(), metadata, func(record VectorRecord) error {
+		if imported == rows {
+			return fmt.Errorf("Error: npy: more metadata lines than rows (%d)", rows)
+		}
+		if _, err := io.ReadFull(matrixReader, row); err != nil {
+			return fmt.Errorf("Error: npy: unable to read the row %d: %w", imported, err)
+		}
+		record.Embedding = make([]float64, dimension)
+		for i := range record.Embedding {
+			if size == 4 {
+				record.Embedding[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(row[4*i:])))
+			} else {
+				record.Embedding[i] = math.Float64frombits(binary.LittleEndian.Uint64(row[8*i:]))
+			}
+		}
+		if _, err := mvs.Save(record); err != nil {
+			return err
+		}
+		imported++
+		return nil
+	})
+	if err != nil {
+		return imported, err
+	}
+	if imported != rows {
+		return imported, fmt.Errorf("Error: npy: %d rows but %d metadata lines", rows, imported)
+	}
+	return imported, nil
+}
+
+// npyMagic starts the .npy files (format version 1.0).
+// https://numpy.org/doc/stable/reference/generated/numpy.lib.format.html
+const npyMagic = "\x93NUMPY\x01\x00"
+
+// npyMaxColumns is the maximum dimension of the imported embeddings.
+const npyMaxColumns = 1 << 20
+
+func writeNPYHeader(w io.Writer, descr string, rows, columns int) error {
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%d, %d), }", descr, rows, columns)
+	// the header (with the magic string and its length) is padded with spaces
+	// to a multiple of 64 bytes, and ends with a newline
+	total := len(npyMagic) + 2 + len(header) + 1
+	header += strings.Repeat(" ", (64-total%64)%64) + "\n"
+
+	buffer := make([]byte, 0, len(npyMagic)+2+len(header))
+	buffer = append(buffer, npyMagic...)
+	buffer = binary.LittleEndian.AppendUint16(buffer, uint16(len(header)))
+	buffer = append(buffer, header...)
+	_, err := w.Write(buffer)
+	return err
+}
+
+var (
+	npyDescr   = regexp.MustCompile(`'descr':\s*'([^']*)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape':\s*\((\d+),\s*(\d+),?\s*\)`)
+)
+
+func readNPYHeader(r io.Reader) (descr string, rows, columns int, err error) {
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return "", 0, 0, fmt.Errorf("Error: npy: unable to read the header: %w", err)
+	}
+	if string(prefix[:6]) != npyMagic[:6] || prefix[6] != 1 {
+		return "", 0, 0, fmt.Errorf("Error: npy: unsupported file (not a version 1.x .npy file)")
+	}
+	header := make([]byte, binary.LittleEndian.Uint16(prefix[8:]))
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", 0, 0, fmt.Errorf("Error: npy: unable to read the header: %w", err)
+	}
+
+	descrMatch := npyDescr.FindSubmatch(header)
+	fortranMatch := npyFortran.FindSubmatch(header)
+	shapeMatch := npyShape.FindSubmatch(header)
+	if descrMatch == nil || fortranMatch == nil || shapeMatch == nil {
+		return "", 0, 0, fmt.Errorf("Error: npy: unsupported header (a 2-D matrix is expected): %s", strings.TrimSpace(string(header)))
+	}
+	descr = string(descrMatch[1])
+	if descr != "<f8" && descr != "<f4" {
+		return "", 0, 0, fmt.Errorf("Error: npy: unsupported type %q (<f8 or <f4 expected)", descr)
+	}
+	if string(fortranMatch[1]) == "True" {
+		return "", 0, 0, fmt.Errorf("Error: npy: unsupported Fortran order")
+	}
+	// the shape is validated before a row is allocated
+	rows, rowsErr := strconv.Atoi(string(shapeMatch[1]))
+	columns, columnsErr := strconv.Atoi(string(shapeMatch[2]))
+	if rowsErr != nil || columnsErr != nil || columns > npyMaxColumns || (columns == 0 && rows > 0) {
+		return "", 0, 0, fmt.Errorf("Error: npy: unsupported shape (%s, %s) (1 to %d columns expected)", shapeMatch[1], shapeMatch[2], npyMaxColumns)
+	}
+	return descr, rows, columns, nil
+}
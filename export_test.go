@@ -0,0 +1,118 @@
+package gollama
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func newExportStore() *MemoryVectorStore {
+	store := NewMemoryVectorStore()
+	store.Save(VectorRecord{Id: "kirk", Text: "James T. Kirk", Embedding: []float64{1.0, 0.2, -0.5}, Attributes: map[string]interface{}{"series": "TOS"}})
+	store.Save(VectorRecord{Id: "picard", Text: "Jean-Luc Picard", Embedding: []float64{1.0, 0.0, 0.123456789}, Reference: "tng.md"})
+	return store
+}
+
+func TestExportImportJSONL(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := newExportStore().ExportJSONL(&buffer); err != nil {
+		t.Fatal("😡:", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"id":"kirk"`) {
+		t.Fatal("😡 unexpected export:\n", buffer.String())
+	}
+
+	imported := NewMemoryVectorStore()
+	count, err := imported.ImportJSONL(&buffer)
+	if err != nil || count != 2 {
+		t.Fatal("😡:", count, err)
+	}
+	picard, _ := imported.Get("picard")
+	if picard.Reference != "tng.md" || picard.Embedding[2] != 0.123456789 {
+		t.Fatal("😡 unexpected record:", picard)
+	}
+	kirk, _ := imported.Get("kirk")
+	if kirk.Attributes["series"] != "TOS" {
+		t.Fatal("😡 unexpected record:", kirk)
+	}
+
+	_, err = NewMemoryVectorStore().ImportJSONL(strings.NewReader(lines[0] + "\n{\"id\":\n"))
+	var decodeError *DecodeError
+	if !errors.As(err, &decodeError) {
+		t.Fatal("😡 expected a *DecodeError:", err)
+	}
+}
+
+func TestExportImportNPY(t *testing.T) {
+	var matrix, metadata bytes.Buffer
+	if err := newExportStore().ExportNPY(&matrix, &metadata); err != nil {
+		t.Fatal("😡:", err)
+	}
+
+	content := matrix.Bytes()
+	headerLength := int(binary.LittleEndian.Uint16(content[8:10]))
+	header := string(content[10 : 10+headerLength])
+	if (10+headerLength)%64 != 0 || !strings.HasPrefix(header, "{'descr': '<f8', 'fortran_order': False, 'shape': (2, 3), }") || !strings.HasSuffix(header, "\n") {
+		t.Fatalf("😡 unexpected header: %q", header)
+	}
+	if len(content) != 10+headerLength+2*3*8 {
+		t.Fatal("😡 unexpected size:", len(content))
+	}
+	if strings.Contains(metadata.String(), "0.123456789") {
+		t.Fatal("😡 the metadata should not contain the embeddings:", metadata.String())
+	}
+
+	imported := NewMemoryVectorStore()
+	count, err := imported.ImportNPY(&matrix, &metadata)
+	if err != nil || count != 2 {
+		t.Fatal("😡:", count, err)
+	}
+	picard, _ := imported.Get("picard")
+	if picard.Reference != "tng.md" || picard.Embedding[2] != 0.123456789 {
+		t.Fatal("😡 unexpected record:", picard)
+	}
+}
+
+// TestImportNPYFloat32 imports a matrix saved by numpy.save(path, matrix.astype(numpy.float32))
+func TestImportNPYFloat32(t *testing.T) {
+	var matrix bytes.Buffer
+	writeNPYHeader(&matrix, "<f4", 2, 2)
+	for _, value := range []float32{1.0, 0.5, 0.25, -1.0} {
+		binary.Write(&matrix, binary.LittleEndian, math.Float32bits(value))
+	}
+	metadata := `{"id":"a","text":"first"}` + "\n" + `{"id":"b","text":"second"}`
+
+	store := NewMemoryVectorStore()
+	if count, err := store.ImportNPY(bytes.NewReader(matrix.Bytes()), strings.NewReader(metadata)); err != nil || count != 2 {
+		t.Fatal("😡:", count, err)
+	}
+	record, _ := store.Get("b")
+	if record.Text != "second" || record.Embedding[0] != 0.25 || record.Embedding[1] != -1.0 {
+		t.Fatal("😡 unexpected record:", record)
+	}
+
+	// one metadata line is missing
+	_, err := NewMemoryVectorStore().ImportNPY(bytes.NewReader(matrix.Bytes()), strings.NewReader(`{"id":"a"}`))
+	if err == nil {
+		t.Fatal("😡 expected an error")
+	}
+}
+
+func TestImportNPYInvalidShape(t *testing.T) {
+	for _, shape := range [][2]string{{"1", "99999999999"}, {"1", "99999999999999999999"}, {"99999999999999999999", "2"}, {"1", "0"}} {
+		var matrix bytes.Buffer
+		header := "{'descr': '<f8', 'fortran_order': False, 'shape': (" + shape[0] + ", " + shape[1] + "), }\n"
+		matrix.WriteString(npyMagic)
+		binary.Write(&matrix, binary.LittleEndian, uint16(len(header)))
+		matrix.WriteString(header)
+
+		_, err := NewMemoryVectorStore().ImportNPY(bytes.NewReader(matrix.Bytes()), strings.NewReader(`{"id":"a"}`))
+		if err == nil || !strings.Contains(err.Error(), "unsupported shape") {
+			t.Fatal("😡 expected an error for the shape", shape, ":", err)
+		}
+	}
+}
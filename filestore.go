@@ -0,0 +1,413 @@
+package gollama
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// === File Vector Store ===
+
+// DefaultCompactionThreshold is the minimum number of log entries
+// before a FileVectorStore is compacted automatically.
+const DefaultCompactionThreshold = 1000
+
+// FileVectorStore is a persistent vector store.
+// The records are kept in memory (for the searches) and every write is appended
+// to a log file (one JSON entry per line), replayed when the store is opened.
+// The log is compacted (rewritten with only the current records) when it contains
+// more than twice as many entries as records (and at least the compaction threshold).
+//
+// The writes are crash-safe: a partially written last entry is dropped when the store is opened,
+// and the compaction writes a new file before atomically replacing the old one.
+//
+// A FileVectorStore is safe for concurrent use.
+type FileVectorStore struct {
+	path                string
+	file                logFile
+	offset              int64 // end of the last complete entry of the log
+	memory              MemoryVectorStore
+	logEntries          int
+	syncWrites          bool
+	compactionThreshold int
+	onCompactionError   func(error)
+	mutex               sync.RWMutex
+}
+
+var _ FilterableVectorStore = (*FileVectorStore)(nil)
+var _ MetricVectorStore = (*FileVectorStore)(nil)
+
+// FileVectorStoreOption configures a FileVectorStore opened with OpenFileVectorStore.
+type FileVectorStoreOption func(*FileVectorStore)
+
+// WithSyncWrites flushes every write to the disk (fsync) before returning.
+// It is slower, but no acknowledged write is lost if the machine crashes.
+func WithSyncWrites(syncWrites bool) FileVectorStoreOption {
+	return func(store *FileVectorStore) {
+		store.syncWrites = syncWrites
+	}
+}
+
+// WithCompactionThreshold sets the minimum number of log entries
+// before an automatic compaction (DefaultCompactionThreshold by default, -1 to disable).
+func WithCompactionThreshold(threshold int) FileVectorStoreOption {
+	return func(store *FileVectorStore) {
+		store.compactionThreshold = threshold
+	}
+}
+
+// WithCompactionErrorHandler sets the function called when an automatic compaction fails.
+// The write that triggered the compaction has succeeded (the log stays valid),
+// and the compaction is tried again after the next write.
+func WithCompactionErrorHandler(handler func(err error)) FileVectorStoreOption {
+	return func(store *FileVectorStore) {
+		store.onCompactionError = handler
+	}
+}
+
+// logFile is the part of *os.File used to write the log.
+type logFile interface {
+	io.WriteCloser
+	io.Seeker
+	Truncate(size int64) error
+	Sync() error
+}
+
+// logEntry is a line of the log file.
+type logEntry struct {
+	Op     string        `json:"op"` // "save" or "delete"
+	Id     string        `json:"id,omitempty"`
+	Record *VectorRecord `json:"record,omitempty"`
+}
+
+// OpenFileVectorStore opens (or creates) the vector store stored in the file at path
+// and loads its records.
+func OpenFileVectorStore(path string, options ...FileVectorStoreOption) (*FileVectorStore, error) {
+	store := &FileVectorStore{
+		path: path,
+		memory: MemoryVectorStore{
+			Records: make(map[string]VectorRecord),
+		},
+		compactionThreshold: DefaultCompactionThreshold,
+	}
+	for _, option := range options {
+		option(store)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.load(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	store.file = file
+	return store, nil
+}
+
+// load replays the log and positions the file at the end of the last complete entry.
+func (store *FileVectorStore) load(file *os.File) error {
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err == io.EOF {
+			// a last line without newline is a partially written entry (crash during a write)
+			break
+		}
+
+		var entry logEntry
+		if jsonErr := json.Unmarshal(bytes.TrimSpace(line), &entry); jsonErr != nil {
+			// an incomplete last entry can be followed by a new complete entry
+			// only if the file is corrupted
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				break
+			}
+			return &DecodeError{Line: string(line), Err: jsonErr}
+		}
+		store.apply(entry)
+		store.logEntries++
+		offset += int64(len(line))
+	}
+
+	// drop the partially written entry (if any)
+	if err := file.Truncate(offset); err != nil {
+		return err
+	}
+	store.offset = offset
+	_, err := file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (store *FileVectorStore) apply(entry logEntry) {
+	switch entry.Op {
+	case "save":
+		if entry.Record != nil {
+			store.memory.spec.update(*entry.Record)
+			store.memory.Records[entry.Record.Id] = *entry.Record
+		}
+	case "delete":
+		delete(store.memory.Records, entry.Id)
+	}
+}
+
+// append writes an entry at the end of the log, then compacts the log if needed.
+// A failed compaction is not an error of the write (see WithCompactionErrorHandler).
+func (store *FileVectorStore) append(entry logEntry) error {
+	if store.file == nil {
+		return errors.New("Error: the vector store is closed")
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	// one write per entry, so an entry is never interleaved with another one
+	_, err = store.file.Write(line)
+	if err == nil && store.syncWrites {
+		err = store.file.Sync()
+	}
+	if err != nil {
+		// drop the partially written entry (ex: disk full),
+		// the next entry must not be written after it
+		store.rollback()
+		return err
+	}
+	store.offset += int64(len(line))
+	store.apply(entry)
+	store.logEntries++
+
+	if store.compactionThreshold >= 0 && store.logEntries >= store.compactionThreshold && store.logEntries > 2*len(store.memory.Records) {
+		if err := store.compact(); err != nil && store.onCompactionError != nil {
+			store.onCompactionError(err)
+		}
+	}
+	return nil
+}
+
+// rollback truncates the log file to the end of the last complete entry.
+func (store *FileVectorStore) rollback() {
+	if store.file.Truncate(store.offset) == nil {
+		store.file.Seek(store.offset, io.SeekStart)
+	}
+}
+
+// Compact rewrites the log file with only the current records.
+func (store *FileVectorStore) Compact() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.compact()
+}
+
+func (store *FileVectorStore) compact() error {
+	if store.file == nil {
+		return errors.New("Error: the vector store is closed")
+	}
+	tmpPath := store.path + ".tmp"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmpFile)
+	var offset int64
+	for _, record := range store.memory.Records {
+		line, err := json.Marshal(logEntry{Op: "save", Record: &record})
+		if err == nil {
+			var written int
+			written, err = writer.Write(append(line, '\n'))
+			offset += int64(written)
+		}
+		if err != nil {
+			tmpFile.Close()
+			os.Remove(tmpPath)
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	// the new file must be on the disk before replacing the old one
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, store.path); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	syncDir(filepath.Dir(store.path))
+
+	store.file.Close()
+	store.file = tmpFile
+	store.offset = offset
+	store.logEntries = len(store.memory.Records)
+	return nil
+}
+
+// syncDir flushes the directory entry of a renamed file (best effort, not supported on every OS).
+func syncDir(path string) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	dir.Sync()
+	dir.Close()
+}
+
+// Close flushes and closes the log file.
+func (store *FileVectorStore) Close() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.file == nil {
+		return nil
+	}
+	err := store.file.Sync()
+	if closeErr := store.file.Close(); err == nil {
+		err = closeErr
+	}
+	store.file = nil
+	return err
+}
+
+// Remove closes the store and deletes its log file (the records are lost).
+func (store *FileVectorStore) Remove() error {
+	if err := store.Close(); err != nil {
+		return err
+	}
+	if err := os.Remove(store.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Path returns the path of the log file.
+func (store *FileVectorStore) Path() string {
+	return store.path
+}
+
+func (store *FileVectorStore) Get(id string) (VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.Get(id)
+}
+
+func (store *FileVectorStore) GetAll() ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.GetAll()
+}
+
+// Model returns the embedding model of the store (empty until a record with a model is saved).
+func (store *FileVectorStore) Model() string {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.Model()
+}
+
+// Dimension returns the dimension of the embeddings of the store (0 until the first record is saved).
+func (store *FileVectorStore) Dimension() int {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.Dimension()
+}
+
+func (store *FileVectorStore) Save(vectorRecord VectorRecord) (VectorRecord, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if err := store.memory.spec.check(vectorRecord); err != nil {
+		return VectorRecord{}, err
+	}
+	previous, exists := store.memory.Records[vectorRecord.Id]
+	vectorRecord = nextVersion(vectorRecord, previous, exists)
+	if err := store.append(logEntry{Op: "save", Record: &vectorRecord}); err != nil {
+		return VectorRecord{}, err
+	}
+	return vectorRecord, nil
+}
+
+func (store *FileVectorStore) Delete(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if _, ok := store.memory.Records[id]; !ok {
+		return nil
+	}
+	return store.append(logEntry{Op: "delete", Id: id})
+}
+
+// DeleteWhere deletes the records matching the filter and returns the number of deleted records
+// (one delete entry is appended to the log per record).
+func (store *FileVectorStore) DeleteWhere(filter Filter) (int, error) {
+	if filter == nil {
+		return 0, ErrNilFilter
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	var ids []string
+	for id, record := range store.memory.Records {
+		if filter(record) {
+			ids = append(ids, id)
+		}
+	}
+	for deleted, id := range ids {
+		if err := store.append(logEntry{Op: "delete", Id: id}); err != nil {
+			return deleted, err
+		}
+	}
+	return len(ids), nil
+}
+
+func (store *FileVectorStore) Count() (int, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.Count()
+}
+
+func (store *FileVectorStore) SearchSimilarities(embeddingFromQuestion VectorRecord, limit float64) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.SearchSimilarities(embeddingFromQuestion, limit)
+}
+
+func (store *FileVectorStore) SearchTopNSimilarities(embeddingFromQuestion VectorRecord, limit float64, max int) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.SearchTopNSimilarities(embeddingFromQuestion, limit, max)
+}
+
+func (store *FileVectorStore) SearchSimilaritiesWithFilter(embeddingFromQuestion VectorRecord, limit float64, filter Filter) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.SearchSimilaritiesWithFilter(embeddingFromQuestion, limit, filter)
+}
+
+func (store *FileVectorStore) SearchTopNSimilaritiesWithFilter(embeddingFromQuestion VectorRecord, limit float64, max int, filter Filter) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.SearchTopNSimilaritiesWithFilter(embeddingFromQuestion, limit, max, filter)
+}
+
+func (store *FileVectorStore) SearchSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, metric Metric) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.SearchSimilaritiesWithMetric(embeddingFromQuestion, limit, metric)
+}
+
+func (store *FileVectorStore) SearchTopNSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, max int, metric Metric) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.memory.SearchTopNSimilaritiesWithMetric(embeddingFromQuestion, limit, max, metric)
+}
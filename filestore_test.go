@@ -0,0 +1,173 @@
+package gollama
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFileVectorStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+
+	store, err := OpenFileVectorStore(path, WithSyncWrites(true))
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	store.Save(VectorRecord{Id: "kirk", Prompt: "James T. Kirk", Embedding: []float64{1.0, 0.2}})
+	store.Save(VectorRecord{Id: "picard", Prompt: "Jean-Luc Picard", Embedding: []float64{1.0, 0.0}})
+	store.Delete("kirk")
+	if err := store.Close(); err != nil {
+		t.Fatal("😡:", err)
+	}
+
+	// simulate a crash during a write
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	file.Write([]byte(`{"op":"save","record":{"id":"spo`))
+	file.Close()
+
+	store, err = OpenFileVectorStore(path)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	defer store.Close()
+
+	if count, _ := store.Count(); count != 1 {
+		t.Fatal("😡 unexpected count:", count)
+	}
+	record, _ := store.Get("picard")
+	if record.Prompt != "Jean-Luc Picard" || record.Embedding[0] != 1.0 {
+		t.Fatal("😡 unexpected record:", record)
+	}
+
+	// the partial entry has been dropped, the next writes are readable
+	store.Save(VectorRecord{Id: "spock", Prompt: "Spock", Embedding: []float64{0.0, 1.0}})
+	content, _ := os.ReadFile(path)
+	if strings.Contains(string(content), `"spo{"op"`) || strings.Count(string(content), "\n") != 4 {
+		t.Fatal("😡 unexpected log:\n", string(content))
+	}
+}
+
+func TestFileVectorStoreCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	os.WriteFile(path, []byte(`{"op":"save","record":{"id":"kirk"}}`+"\n"+`{"op":`+"\n"+`{"op":"save","record":{"id":"picard"}}`+"\n"), 0644)
+
+	_, err := OpenFileVectorStore(path)
+	if err == nil {
+		t.Fatal("😡 a corrupted store should not be opened")
+	}
+}
+
+func TestFileVectorStoreCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+
+	store, err := OpenFileVectorStore(path, WithCompactionThreshold(10))
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	for idx := 0; idx < 25; idx++ {
+		// always the same 2 records
+		store.Save(VectorRecord{Id: strconv.Itoa(idx % 2), Prompt: "version " + strconv.Itoa(idx)})
+	}
+	content, _ := os.ReadFile(path)
+	if lines := strings.Count(string(content), "\n"); lines >= 10 {
+		t.Fatal("😡 the log should be compacted, lines:", lines)
+	}
+	store.Close()
+
+	store, err = OpenFileVectorStore(path)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	defer store.Close()
+	record, _ := store.Get("0")
+	if record.Prompt != "version 24" {
+		t.Fatal("😡 unexpected record after compaction:", record)
+	}
+	if count, _ := store.Count(); count != 2 {
+		t.Fatal("😡 unexpected count:", count)
+	}
+}
+
+// failingFile writes only the first remaining bytes, then fails (ex: disk full).
+type failingFile struct {
+	logFile
+	remaining int
+}
+
+func (file *failingFile) Write(data []byte) (int, error) {
+	if len(data) <= file.remaining {
+		file.remaining -= len(data)
+		return file.logFile.Write(data)
+	}
+	written, _ := file.logFile.Write(data[:file.remaining])
+	file.remaining = 0
+	return written, errors.New("no space left on device")
+}
+
+func TestFileVectorStorePartialWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	store, err := OpenFileVectorStore(path)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	store.Save(VectorRecord{Id: "kirk", Embedding: []float64{1.0, 0.2}})
+
+	file := store.file
+	store.file = &failingFile{logFile: file, remaining: 10}
+	if _, err := store.Save(VectorRecord{Id: "spock", Embedding: []float64{0.0, 1.0}}); err == nil {
+		t.Fatal("😡 the write should fail")
+	}
+	store.file = file
+	if _, err := store.Save(VectorRecord{Id: "picard", Embedding: []float64{1.0, 0.0}}); err != nil {
+		t.Fatal("😡:", err)
+	}
+	store.Close()
+
+	// the partial entry has been dropped: the log is not corrupted
+	store, err = OpenFileVectorStore(path)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	defer store.Close()
+	records, _ := store.GetAll()
+	if len(records) != 2 {
+		t.Fatal("😡 unexpected records:", records)
+	}
+	if _, err := store.Get("spock"); err == nil {
+		t.Fatal("😡 the failed write should not be saved")
+	}
+}
+
+func TestFileVectorStoreCompactionFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	// the temporary file of the compaction cannot be created
+	if err := os.Mkdir(path+".tmp", 0755); err != nil {
+		t.Fatal("😡:", err)
+	}
+	var compactionErrors []error
+	store, err := OpenFileVectorStore(path, WithCompactionThreshold(2), WithCompactionErrorHandler(func(err error) {
+		compactionErrors = append(compactionErrors, err)
+	}))
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	for version := 1; version <= 3; version++ {
+		record, err := store.Save(VectorRecord{Id: "kirk", Embedding: []float64{1.0, 0.0}})
+		if err != nil || record.Version != version {
+			t.Fatal("😡 the write should succeed:", record, err)
+		}
+	}
+	store.Close()
+	if len(compactionErrors) == 0 {
+		t.Fatal("😡 the compaction errors should be reported")
+	}
+
+	store, _ = OpenFileVectorStore(path)
+	defer store.Close()
+	if record, err := store.Get("kirk"); err != nil || record.Version != 3 {
+		t.Fatal("😡 unexpected record:", record, err)
+	}
+}
@@ -0,0 +1,147 @@
+package gollama
+
+import (
+	"reflect"
+)
+
+// === Metadata filters ===
+
+// Filter selects the vector records to compare during a search
+// (it is applied before ranking). The filters are built with
+// Eq, In, Gt, Gte, Lt, Lte, Between and combined with And, Or and Not:
+//
+//	filter := gollama.And(
+//		gollama.Eq("source", "star-trek.md"),
+//		gollama.In("lang", "en", "fr"),
+//		gollama.Gte("year", 2017),
+//	)
+//	similarities, err := store.SearchTopNSimilaritiesWithFilter(embeddingFromQuestion, 0.5, 3, filter)
+type Filter func(record VectorRecord) bool
+
+// Eq selects the records with the attribute key equal to value.
+// The numbers are compared by value, whatever their type (3 == 3.0).
+func Eq(key string, value interface{}) Filter {
+	return func(record VectorRecord) bool {
+		attribute, ok := record.Attributes[key]
+		return ok && equalValues(attribute, value)
+	}
+}
+
+// In selects the records with the attribute key equal to one of the values.
+func In(key string, values ...interface{}) Filter {
+	return func(record VectorRecord) bool {
+		attribute, ok := record.Attributes[key]
+		if !ok {
+			return false
+		}
+		for _, value := range values {
+			if equalValues(attribute, value) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Gt selects the records with the numeric attribute key greater than value.
+func Gt(key string, value float64) Filter {
+	return numericFilter(key, func(attribute float64) bool { return attribute > value })
+}
+
+// Gte selects the records with the numeric attribute key greater than or equal to value.
+func Gte(key string, value float64) Filter {
+	return numericFilter(key, func(attribute float64) bool { return attribute >= value })
+}
+
+// Lt selects the records with the numeric attribute key less than value.
+func Lt(key string, value float64) Filter {
+	return numericFilter(key, func(attribute float64) bool { return attribute < value })
+}
+
+// Lte selects the records with the numeric attribute key less than or equal to value.
+func Lte(key string, value float64) Filter {
+	return numericFilter(key, func(attribute float64) bool { return attribute <= value })
+}
+
+// Between selects the records with the numeric attribute key in the range [min, max].
+func Between(key string, min, max float64) Filter {
+	return numericFilter(key, func(attribute float64) bool { return attribute >= min && attribute <= max })
+}
+
+// And selects the records matching all the filters.
+func And(filters ...Filter) Filter {
+	return func(record VectorRecord) bool {
+		for _, filter := range filters {
+			if !filter(record) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or selects the records matching at least one of the filters.
+func Or(filters ...Filter) Filter {
+	return func(record VectorRecord) bool {
+		for _, filter := range filters {
+			if filter(record) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not selects the records not matching the filter.
+func Not(filter Filter) Filter {
+	return func(record VectorRecord) bool {
+		return !filter(record)
+	}
+}
+
+func numericFilter(key string, compare func(attribute float64) bool) Filter {
+	return func(record VectorRecord) bool {
+		attribute, ok := toFloat(record.Attributes[key])
+		return ok && compare(attribute)
+	}
+}
+
+// toFloat converts a number of any type to a float64
+// (the numbers are float64 after a JSON round trip).
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case float32:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	case int8:
+		return float64(number), true
+	case int16:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case uint:
+		return float64(number), true
+	case uint8:
+		return float64(number), true
+	case uint16:
+		return float64(number), true
+	case uint32:
+		return float64(number), true
+	case uint64:
+		return float64(number), true
+	}
+	return 0.0, false
+}
+
+func equalValues(a, b interface{}) bool {
+	if numberA, ok := toFloat(a); ok {
+		numberB, ok := toFloat(b)
+		return ok && numberA == numberB
+	}
+	return reflect.DeepEqual(a, b)
+}
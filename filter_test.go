@@ -0,0 +1,88 @@
+package gollama
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var filterRecords = []VectorRecord{
+	{Id: "kirk", Embedding: []float64{1.0, 0.2}, Attributes: map[string]interface{}{"series": "TOS", "year": 1966, "lang": "en"}},
+	{Id: "picard", Embedding: []float64{1.0, 0.0}, Attributes: map[string]interface{}{"series": "TNG", "year": 1987, "lang": "en"}},
+	{Id: "burnham", Embedding: []float64{0.9, 0.1}, Attributes: map[string]interface{}{"series": "DIS", "year": 2017, "lang": "fr"}},
+	{Id: "spock", Embedding: []float64{0.8, 0.3}},
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{"Eq", Eq("series", "TNG"), []string{"picard"}},
+		{"Eq number", Eq("year", 1966.0), []string{"kirk"}},
+		{"In", In("series", "TOS", "DIS"), []string{"kirk", "burnham"}},
+		{"Gte", Gte("year", 1987), []string{"picard", "burnham"}},
+		{"Lt", Lt("year", 1987), []string{"kirk"}},
+		{"Between", Between("year", 1980, 2000), []string{"picard"}},
+		{"And", And(Eq("lang", "en"), Gt("year", 1970)), []string{"picard"}},
+		{"Or", Or(Eq("lang", "fr"), Lte("year", 1966)), []string{"kirk", "burnham"}},
+		{"Not", Not(Eq("lang", "en")), []string{"burnham", "spock"}},
+	}
+
+	for _, test := range tests {
+		var selected []string
+		for _, record := range filterRecords {
+			if test.filter(record) {
+				selected = append(selected, record.Id)
+			}
+		}
+		if len(selected) != len(test.expected) {
+			t.Fatal("😡", test.name, "unexpected records:", selected)
+		}
+		for idx := range selected {
+			if selected[idx] != test.expected[idx] {
+				t.Fatal("😡", test.name, "unexpected records:", selected)
+			}
+		}
+	}
+}
+
+func TestSearchWithFilter(t *testing.T) {
+	store := NewMemoryVectorStore()
+	for _, record := range filterRecords {
+		// the numbers are float64 after a JSON round trip
+		data, _ := json.Marshal(record)
+		var decoded VectorRecord
+		json.Unmarshal(data, &decoded)
+		store.Save(decoded)
+	}
+	question := VectorRecord{Embedding: []float64{1.0, 0.0}}
+
+	similarities, err := store.SearchTopNSimilaritiesWithFilter(question, 0.5, 2, Eq("lang", "en"))
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(similarities) != 2 || similarities[0].Id != "picard" || similarities[1].Id != "kirk" {
+		t.Fatal("😡 unexpected similarities:", similarities)
+	}
+
+	similarities, err = store.SearchSimilaritiesWithFilter(question, 0.5, And(Gte("year", 2000), In("series", "DIS")))
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(similarities) != 1 || similarities[0].Id != "burnham" {
+		t.Fatal("😡 unexpected similarities:", similarities)
+	}
+
+	hnsw := NewHNSWVectorStore(DefaultHNSWConfig())
+	for _, record := range filterRecords {
+		hnsw.Save(record)
+	}
+	similarities, err = hnsw.SearchTopNSimilaritiesWithFilter(question, 0.5, 3, Eq("lang", "fr"))
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(similarities) != 1 || similarities[0].Id != "burnham" {
+		t.Fatal("😡 unexpected similarities:", similarities)
+	}
+}
@@ -0,0 +1,130 @@
+package gollama
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// === Completion ===
+
+// GenerateQuery is the request of the /api/generate endpoint.
+// https://github.com/ollama/ollama/blob/main/docs/api.md#generate-a-completion
+type GenerateQuery struct {
+	Model  string   `json:"model"`
+	Prompt string   `json:"prompt"`
+	Suffix string   `json:"suffix,omitempty"` // text after the model response (fill-in-the-middle)
+	Images []string `json:"images,omitempty"` // base64-encoded images (for multimodal models)
+
+	// Context is the context returned by a previous GenerateAnswer,
+	// it allows to continue a stateful completion.
+	Context []int `json:"context,omitempty"`
+
+	Options Options `json:"options"`
+	Stream  bool    `json:"stream"`
+
+	Format    string `json:"format,omitempty"`
+	KeepAlive string `json:"keep_alive,omitempty"` // ex: "5m", "0" to unload the model
+	Raw       bool   `json:"raw,omitempty"`        // no formatting is applied to the prompt
+	System    string `json:"system,omitempty"`
+	Template  string `json:"template,omitempty"`
+
+	TokenHeaderName  string `json:"-"`
+	TokenHeaderValue string `json:"-"`
+}
+
+// GenerateAnswer is the response of the /api/generate endpoint.
+type GenerateAnswer struct {
+	Model      string    `json:"model"`
+	CreatedAt  time.Time `json:"created_at"`
+	Response   string    `json:"response"`
+	Done       bool      `json:"done"`
+	DoneReason string    `json:"done_reason,omitempty"`
+
+	// Context is an encoding of the conversation,
+	// send it back with the next GenerateQuery to keep a conversational memory.
+	Context []int `json:"context,omitempty"`
+
+	Metrics
+}
+
+// IsTruncated reports whether the generation has been stopped
+// because the maximum number of tokens (NumPredict) or the context size has been reached.
+func (answer *GenerateAnswer) IsTruncated() bool {
+	return answer.DoneReason == "length"
+}
+
+func Generate(url string, query GenerateQuery) (GenerateAnswer, error) {
+	return NewClient(url).Generate(query)
+}
+
+func GenerateStream(url string, query GenerateQuery, onChunk func(GenerateAnswer) error) (GenerateAnswer, error) {
+	return NewClient(url).GenerateStream(query, onChunk)
+}
+
+// GenerateContext is like Generate but the request is canceled when ctx is done.
+func GenerateContext(ctx context.Context, url string, query GenerateQuery) (GenerateAnswer, error) {
+	return NewClient(url).GenerateContext(ctx, query)
+}
+
+// GenerateStreamContext is like GenerateStream but the stream is stopped when ctx is done.
+func GenerateStreamContext(ctx context.Context, url string, query GenerateQuery, onChunk func(GenerateAnswer) error) (GenerateAnswer, error) {
+	return NewClient(url).GenerateStreamContext(ctx, query, onChunk)
+}
+
+// Generate sends the prompt of the query to the model and returns the complete answer.
+func (c *Client) Generate(query GenerateQuery) (GenerateAnswer, error) {
+	return c.GenerateContext(context.Background(), query)
+}
+
+// GenerateContext is like Generate but the request is canceled when ctx is done.
+func (c *Client) GenerateContext(ctx context.Context, query GenerateQuery) (GenerateAnswer, error) {
+	query.Stream = false
+
+	var answer GenerateAnswer
+	err := c.sendJSON(ctx, http.MethodPost, "/api/generate", query, query.TokenHeaderName, query.TokenHeaderValue, &answer)
+	if err != nil {
+		return GenerateAnswer{}, err
+	}
+	return answer, nil
+}
+
+// GenerateStream sends the prompt of the query to the model
+// and calls onChunk for every chunk of the answer.
+// It returns the full answer (with the context and the metrics of the last chunk)
+// when the stream is over.
+// If onChunk returns an error, the stream is stopped and the error is returned.
+func (c *Client) GenerateStream(query GenerateQuery, onChunk func(GenerateAnswer) error) (GenerateAnswer, error) {
+	return c.GenerateStreamContext(context.Background(), query, onChunk)
+}
+
+// GenerateStreamContext is like GenerateStream but the stream is stopped when ctx is done:
+// the request is canceled and ctx.Err() is returned.
+func (c *Client) GenerateStreamContext(ctx context.Context, query GenerateQuery, onChunk func(GenerateAnswer) error) (GenerateAnswer, error) {
+	query.Stream = true
+
+	resp, err := c.send(ctx, http.MethodPost, "/api/generate", query, query.TokenHeaderName, query.TokenHeaderValue)
+	if err != nil {
+		return GenerateAnswer{}, err
+	}
+	defer resp.Body.Close()
+
+	var fullAnswer GenerateAnswer
+	err = decodeStream(ctx, resp.Body, func(answer GenerateAnswer) error {
+		fullAnswer.Model = answer.Model
+		fullAnswer.CreatedAt = answer.CreatedAt
+		fullAnswer.Done = answer.Done
+		fullAnswer.Response += answer.Response
+		if answer.Done {
+			// the context and the metrics are sent with the last chunk
+			fullAnswer.DoneReason = answer.DoneReason
+			fullAnswer.Context = answer.Context
+			fullAnswer.Metrics = answer.Metrics
+		}
+		return onChunk(answer)
+	})
+	if err != nil {
+		return GenerateAnswer{}, err
+	}
+	return fullAnswer, nil
+}
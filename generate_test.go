@@ -0,0 +1,60 @@
+package gollama
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			t.Errorf("😡 unexpected path: %s", r.URL.Path)
+		}
+		var query GenerateQuery
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			t.Errorf("😡: %v", err)
+		}
+		if query.Suffix != "}" || !reflect.DeepEqual(query.Context, []int{1, 2, 3}) {
+			t.Errorf("😡 unexpected query: %+v", query)
+		}
+		if query.Stream {
+			w.Write([]byte(`{"model":"qwen2.5-coder","response":"return ","done":false}` + "\n"))
+			w.Write([]byte(`{"model":"qwen2.5-coder","response":"a + b","done":false}` + "\n"))
+			w.Write([]byte(`{"model":"qwen2.5-coder","response":"","done":true,"done_reason":"stop","context":[1,2,3,4],"eval_count":3}` + "\n"))
+			return
+		}
+		w.Write([]byte(`{"model":"qwen2.5-coder","response":"return a + b","done":true,"done_reason":"stop","context":[1,2,3,4]}`))
+	}))
+	defer server.Close()
+
+	query := GenerateQuery{
+		Model:   "qwen2.5-coder",
+		Prompt:  "func add(a, b int) int {",
+		Suffix:  "}",
+		Context: []int{1, 2, 3},
+		Options: DefaultOptions(),
+	}
+
+	answer, err := Generate(server.URL, query)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if answer.Response != "return a + b" || len(answer.Context) != 4 {
+		t.Fatal("😡 unexpected answer:", answer)
+	}
+
+	chunks := 0
+	fullAnswer, err := GenerateStream(server.URL, query, func(answer GenerateAnswer) error {
+		chunks++
+		return nil
+	})
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if chunks != 3 || fullAnswer.Response != "return a + b" || len(fullAnswer.Context) != 4 || fullAnswer.EvalCount != 3 {
+		t.Fatal("😡 unexpected full answer:", fullAnswer)
+	}
+}
@@ -1,16 +1,17 @@
 package gollama
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"io"
 	"math"
 	"net/http"
 	"sort"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 )
 
 type FunctionTool struct {
@@ -59,11 +60,50 @@ func (m *Message) FirstToolCallToJSONString() (string, error) {
 	return jsonString, nil
 }
 
+// Metrics are the statistics returned by Ollama with the last chunk of a generation.
+// The durations are sent in nanoseconds by Ollama.
+type Metrics struct {
+	TotalDuration      time.Duration `json:"total_duration,omitempty"`       // time spent generating the response
+	LoadDuration       time.Duration `json:"load_duration,omitempty"`        // time spent loading the model
+	PromptEvalCount    int           `json:"prompt_eval_count,omitempty"`    // number of tokens in the prompt
+	PromptEvalDuration time.Duration `json:"prompt_eval_duration,omitempty"` // time spent evaluating the prompt
+	EvalCount          int           `json:"eval_count,omitempty"`           // number of tokens in the response
+	EvalDuration       time.Duration `json:"eval_duration,omitempty"`        // time spent generating the response tokens
+}
+
+// TokensPerSecond returns the generation throughput (response tokens per second).
+// It returns 0 if the metrics are not available.
+func (m Metrics) TokensPerSecond() float64 {
+	if m.EvalDuration <= 0 {
+		return 0.0
+	}
+	return float64(m.EvalCount) / m.EvalDuration.Seconds()
+}
+
+// PromptTokensPerSecond returns the prompt evaluation throughput (prompt tokens per second).
+// It returns 0 if the metrics are not available.
+func (m Metrics) PromptTokensPerSecond() float64 {
+	if m.PromptEvalDuration <= 0 {
+		return 0.0
+	}
+	return float64(m.PromptEvalCount) / m.PromptEvalDuration.Seconds()
+}
+
 // Answer
 type Answer struct {
-	Model   string  `json:"model"`
-	Message Message `json:"message"`
-	Done    bool    `json:"done"`
+	Model      string    `json:"model"`
+	CreatedAt  time.Time `json:"created_at"`
+	Message    Message   `json:"message"`
+	Done       bool      `json:"done"`
+	DoneReason string    `json:"done_reason,omitempty"` // ex: "stop", "length", "load"
+
+	Metrics
+}
+
+// IsTruncated reports whether the generation has been stopped
+// because the maximum number of tokens (NumPredict) or the context size has been reached.
+func (answer *Answer) IsTruncated() bool {
+	return answer.DoneReason == "length"
 }
 
 func (answer *Answer) ToJsonString() string {
@@ -226,7 +266,7 @@ func (query *Query) ToJsonString() string {
 	return jsonString
 }
 
-// === Cosine distance ===
+// === Cosine similarity ===
 func dotProduct(v1 []float64, v2 []float64) float64 {
 	// Calculate the dot product of two vectors
 	sum := 0.0
@@ -236,8 +276,13 @@ func dotProduct(v1 []float64, v2 []float64) float64 {
 	return sum
 }
 
-func CosineDistance(v1, v2 []float64) float64 {
-	// Calculate the cosine distance between two vectors
+// CosineSimilarity returns the cosine similarity of two vectors:
+// between -1 and 1, the higher, the more similar (1 = same direction).
+// The similarity of vectors of different lengths is 0 (use CosineDistance to get a *DimensionError).
+func CosineSimilarity(v1, v2 []float64) float64 {
+	if len(v1) != len(v2) {
+		return 0.0
+	}
 	product := dotProduct(v1, v2)
 	norm1 := math.Sqrt(dotProduct(v1, v1))
 	norm2 := math.Sqrt(dotProduct(v2, v2))
@@ -248,17 +293,46 @@ func CosineDistance(v1, v2 []float64) float64 {
 	return product / (norm1 * norm2)
 }
 
+// CosineDistance returns the cosine similarity of two vectors,
+// or a *DimensionError if the vectors have different lengths.
+//
+// Deprecated: despite its name, CosineDistance returns a similarity (the higher, the more similar).
+// Use CosineSimilarity (after checking the lengths), or CosineMetric.Score.
+func CosineDistance(v1, v2 []float64) (float64, error) {
+	if len(v1) != len(v2) {
+		return 0.0, &DimensionError{Expected: len(v1), Actual: len(v2)}
+	}
+	return CosineSimilarity(v1, v2), nil
+}
+
 // === Embeddings ===
 type VectorRecord struct {
 	Id        string    `json:"id"`
 	Prompt    string    `json:"prompt"`
 	Embedding []float64 `json:"embedding"`
-
+	// Model is the embedding model used to create the embedding (ex: "all-minilm"),
+	// set by CreateEmbedding and CreateEmbeddings. The vector stores reject the records
+	// and the queries created with another model than their first record.
+	Model string `json:"model,omitempty"`
+	// Version is the number of times the record has been saved, set by the vector stores.
+	Version int `json:"version,omitempty"`
+	// ContentHash is the hash of the content of the record (see ContentHash), set by the vector stores.
+	ContentHash string `json:"contentHash,omitempty"`
+
+	// CosineDistance is the cosine similarity with the question,
+	// set by the searches (despite its name, the higher, the more similar).
 	CosineDistance float64
+	// Score is the similarity (or the distance) with the question,
+	// for the Metric used by the search.
+	Score float64 `json:"score,omitempty"`
 
 	Reference string `json:"reference"`
 	MetaData  string `json:"metaData"`
 	Text      string `json:"text"`
+
+	// Attributes are the structured metadata of the record (source, language, tenant...),
+	// used to filter the searches (see Filter).
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // https://github.com/ollama/ollama/blob/main/docs/api.md#request-22
@@ -276,23 +350,28 @@ type EmbeddingResponse struct {
 
 // Create embedding
 func CreateEmbedding(ollamaUrl string, query Query4Embedding, id string) (VectorRecord, error) {
-	jsonData, err := json.Marshal(query)
-	if err != nil {
-		return VectorRecord{}, err
-	}
+	return NewClient(ollamaUrl).CreateEmbedding(query, id)
+}
+
+// CreateEmbeddingContext is like CreateEmbedding but the request is canceled when ctx is done.
+func CreateEmbeddingContext(ctx context.Context, ollamaUrl string, query Query4Embedding, id string) (VectorRecord, error) {
+	return NewClient(ollamaUrl).CreateEmbeddingContext(ctx, query, id)
+}
+
+// CreateEmbedding creates an embedding from the prompt of the query
+// and returns it as a VectorRecord with the given id.
+func (c *Client) CreateEmbedding(query Query4Embedding, id string) (VectorRecord, error) {
+	return c.CreateEmbeddingContext(context.Background(), query, id)
+}
 
-	req, err := http.NewRequest(http.MethodPost, ollamaUrl+"/api/embeddings", bytes.NewBuffer(jsonData))
+// CreateEmbeddingContext is like CreateEmbedding but the request is canceled when ctx is done.
+func (c *Client) CreateEmbeddingContext(ctx context.Context, query Query4Embedding, id string) (VectorRecord, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/embeddings", query, query.TokenHeaderName, query.TokenHeaderValue)
 	if err != nil {
 		return VectorRecord{}, err
 	}
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-
-	if query.TokenHeaderName != "" && query.TokenHeaderValue != "" {
-		req.Header.Set(query.TokenHeaderName, query.TokenHeaderValue)
-	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return VectorRecord{}, err
 	}
@@ -300,7 +379,7 @@ func CreateEmbedding(ollamaUrl string, query Query4Embedding, id string) (Vector
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return VectorRecord{}, errors.New("Error: status code: " + resp.Status)
+		return VectorRecord{}, newAPIError(resp)
 	}
 	body, err := io.ReadAll(resp.Body)
 
@@ -309,7 +388,7 @@ func CreateEmbedding(ollamaUrl string, query Query4Embedding, id string) (Vector
 	}
 
 	var answer EmbeddingResponse
-	err = json.Unmarshal([]byte(string(body)), &answer)
+	err = json.Unmarshal(body, &answer)
 	if err != nil {
 		return VectorRecord{}, err
 	}
@@ -317,6 +396,7 @@ func CreateEmbedding(ollamaUrl string, query Query4Embedding, id string) (Vector
 	vectorRecord := VectorRecord{
 		Prompt:    query.Prompt,
 		Embedding: answer.Embedding,
+		Model:     query.Model,
 		Id:        id,
 	}
 
@@ -325,15 +405,40 @@ func CreateEmbedding(ollamaUrl string, query Query4Embedding, id string) (Vector
 
 // === Vector Store
 
+// MemoryVectorStore is an in-memory vector store.
+// It is safe for concurrent use through its methods
+// (the Records map must not be accessed directly while the store is in use).
+//
+// The embedding model and dimension of the store are recorded on the first insert:
+// the records and the queries with another model or dimension are rejected
+// with a *ModelError or a *DimensionError.
 type MemoryVectorStore struct {
 	Records map[string]VectorRecord
+
+	spec  embeddingSpec
+	mutex sync.RWMutex
+}
+
+// NewMemoryVectorStore creates an empty MemoryVectorStore.
+func NewMemoryVectorStore() *MemoryVectorStore {
+	return &MemoryVectorStore{
+		Records: make(map[string]VectorRecord),
+	}
 }
 
 func (mvs *MemoryVectorStore) Get(id string) (VectorRecord, error) {
-	return mvs.Records[id], nil
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	record, ok := mvs.Records[id]
+	if !ok {
+		return VectorRecord{}, recordNotFound(id)
+	}
+	return record, nil
 }
 
 func (mvs *MemoryVectorStore) GetAll() ([]VectorRecord, error) {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
 	var records []VectorRecord
 	for _, record := range mvs.Records {
 		records = append(records, record)
@@ -341,11 +446,66 @@ func (mvs *MemoryVectorStore) GetAll() ([]VectorRecord, error) {
 	return records, nil
 }
 
+// Model returns the embedding model of the store (empty until a record with a model is saved).
+func (mvs *MemoryVectorStore) Model() string {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	return mvs.spec.model
+}
+
+// Dimension returns the dimension of the embeddings of the store (0 until the first record is saved).
+func (mvs *MemoryVectorStore) Dimension() int {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	return mvs.spec.dimension
+}
+
 func (mvs *MemoryVectorStore) Save(vectorRecord VectorRecord) (VectorRecord, error) {
+	mvs.mutex.Lock()
+	defer mvs.mutex.Unlock()
+	if err := mvs.spec.check(vectorRecord); err != nil {
+		return VectorRecord{}, err
+	}
+	mvs.spec.update(vectorRecord)
+	if mvs.Records == nil {
+		mvs.Records = make(map[string]VectorRecord)
+	}
+	previous, exists := mvs.Records[vectorRecord.Id]
+	vectorRecord = nextVersion(vectorRecord, previous, exists)
 	mvs.Records[vectorRecord.Id] = vectorRecord
 	return vectorRecord, nil
 }
 
+func (mvs *MemoryVectorStore) Delete(id string) error {
+	mvs.mutex.Lock()
+	defer mvs.mutex.Unlock()
+	delete(mvs.Records, id)
+	return nil
+}
+
+// DeleteWhere deletes the records matching the filter and returns the number of deleted records.
+func (mvs *MemoryVectorStore) DeleteWhere(filter Filter) (int, error) {
+	if filter == nil {
+		return 0, ErrNilFilter
+	}
+	mvs.mutex.Lock()
+	defer mvs.mutex.Unlock()
+	deleted := 0
+	for id, record := range mvs.Records {
+		if filter(record) {
+			delete(mvs.Records, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (mvs *MemoryVectorStore) Count() (int, error) {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	return len(mvs.Records), nil
+}
+
 // SearchSimilarities searches for vector records in the MemoryVectorStore that have a cosine distance similarity greater than or equal to the given limit.
 //
 // Parameters:
@@ -356,13 +516,29 @@ func (mvs *MemoryVectorStore) Save(vectorRecord VectorRecord) (VectorRecord, err
 //   - VectorRecord: a slice of vector records that have a cosine distance similarity greater than or equal to the limit.
 //   - error: an error if any occurred during the search.
 func (mvs *MemoryVectorStore) SearchSimilarities(embeddingFromQuestion VectorRecord, limit float64) ([]VectorRecord, error) {
+	return mvs.SearchSimilaritiesWithFilter(embeddingFromQuestion, limit, nil)
+}
+
+// SearchSimilaritiesWithFilter is like SearchSimilarities,
+// but only the records matching the filter are compared (a nil filter matches all the records).
+func (mvs *MemoryVectorStore) SearchSimilaritiesWithFilter(embeddingFromQuestion VectorRecord, limit float64, filter Filter) ([]VectorRecord, error) {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	if err := mvs.spec.check(embeddingFromQuestion); err != nil {
+		return nil, err
+	}
+
 	// search similarities
 	var records []VectorRecord
 
 	for _, v := range mvs.Records {
-		distance := CosineDistance(embeddingFromQuestion.Embedding, v.Embedding)
-		if distance >= limit {
-			v.CosineDistance = distance
+		if filter != nil && !filter(v) {
+			continue
+		}
+		similarity := CosineSimilarity(embeddingFromQuestion.Embedding, v.Embedding)
+		if similarity >= limit {
+			v.CosineDistance = similarity
+			v.Score = similarity
 			records = append(records, v)
 		}
 	}
@@ -382,6 +558,53 @@ func (mvs *MemoryVectorStore) SearchTopNSimilarities(embeddingFromQuestion Vecto
 	return getTopNVectorRecords(records, max), nil
 }
 
+// SearchTopNSimilaritiesWithFilter is like SearchTopNSimilarities,
+// but only the records matching the filter are compared (a nil filter matches all the records).
+func (mvs *MemoryVectorStore) SearchTopNSimilaritiesWithFilter(embeddingFromQuestion VectorRecord, limit float64, max int, filter Filter) ([]VectorRecord, error) {
+	records, err := mvs.SearchSimilaritiesWithFilter(embeddingFromQuestion, limit, filter)
+	if err != nil {
+		return nil, err
+	}
+	return getTopNVectorRecords(records, max), nil
+}
+
+// SearchSimilaritiesWithMetric searches for the vector records that are similar to the question
+// according to the metric: with a score greater than or equal to limit for a similarity metric
+// (ex: CosineMetric), less than or equal to limit for a distance metric (ex: EuclideanMetric).
+// The Score field of the records is set.
+func (mvs *MemoryVectorStore) SearchSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, metric Metric) ([]VectorRecord, error) {
+	mvs.mutex.RLock()
+	defer mvs.mutex.RUnlock()
+	if metric.comparesEmbeddings() {
+		if err := mvs.spec.check(embeddingFromQuestion); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []VectorRecord
+	for _, v := range mvs.Records {
+		score := metric.Score(embeddingFromQuestion, v)
+		if metric.Passes(score, limit) {
+			v.Score = score
+			if metric == CosineMetric {
+				v.CosineDistance = score
+			}
+			records = append(records, v)
+		}
+	}
+	return records, nil
+}
+
+// SearchTopNSimilaritiesWithMetric is like SearchSimilaritiesWithMetric,
+// but returns at most max records, the most similar first.
+func (mvs *MemoryVectorStore) SearchTopNSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, max int, metric Metric) ([]VectorRecord, error) {
+	records, err := mvs.SearchSimilaritiesWithMetric(embeddingFromQuestion, limit, metric)
+	if err != nil {
+		return nil, err
+	}
+	return sortByScore(records, metric, max), nil
+}
+
 func getTopNVectorRecords(records []VectorRecord, max int) []VectorRecord {
 	// Sort the records slice in descending order based on CosineDistance
 	sort.Slice(records, func(i, j int) bool {
@@ -397,33 +620,46 @@ func getTopNVectorRecords(records []VectorRecord, max int) []VectorRecord {
 
 // === Chat Completion ===
 func Chat(url string, query Query) (Answer, error) {
+	return NewClient(url).Chat(query)
+}
 
-	query.Stream = false
+func ChatStream(url string, query Query, onChunk func(Answer) error) (Answer, error) {
+	return NewClient(url).ChatStream(query, onChunk)
+}
 
-	jsonQuery, err := json.Marshal(query)
-	if err != nil {
-		return Answer{}, err
-	}
+// ChatContext is like Chat but the request is canceled when ctx is done.
+func ChatContext(ctx context.Context, url string, query Query) (Answer, error) {
+	return NewClient(url).ChatContext(ctx, query)
+}
+
+// ChatStreamContext is like ChatStream but the stream is stopped when ctx is done.
+func ChatStreamContext(ctx context.Context, url string, query Query, onChunk func(Answer) error) (Answer, error) {
+	return NewClient(url).ChatStreamContext(ctx, query, onChunk)
+}
+
+// Chat sends the messages of the query to the model and returns the complete answer.
+func (c *Client) Chat(query Query) (Answer, error) {
+	return c.ChatContext(context.Background(), query)
+}
+
+// ChatContext is like Chat but the request is canceled when ctx is done.
+func (c *Client) ChatContext(ctx context.Context, query Query) (Answer, error) {
+
+	query.Stream = false
 
-	req, err := http.NewRequest(http.MethodPost, url+"/api/chat", bytes.NewBuffer(jsonQuery))
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/chat", query, query.TokenHeaderName, query.TokenHeaderValue)
 	if err != nil {
 		return Answer{}, err
 	}
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
-	if query.TokenHeaderName != "" && query.TokenHeaderValue != "" {
-		req.Header.Set(query.TokenHeaderName, query.TokenHeaderValue)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return Answer{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return Answer{}, errors.New("Error: status code: " + resp.Status)
+		return Answer{}, newAPIError(resp)
 	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -441,68 +677,59 @@ func Chat(url string, query Query) (Answer, error) {
 
 }
 
-func ChatStream(url string, query Query, onChunk func(Answer) error) (Answer, error) {
+// ChatStream sends the messages of the query to the model
+// and calls onChunk for every chunk of the answer.
+// It returns the full answer when the stream is over.
+// If onChunk returns an error, the stream is stopped and the error is returned.
+//
+// The status code is checked before streaming (an *APIError is returned if it is not 200),
+// an error sent by Ollama during the stream is returned as a *StreamError
+// and a chunk that cannot be decoded is returned as a *DecodeError.
+func (c *Client) ChatStream(query Query, onChunk func(Answer) error) (Answer, error) {
+	return c.ChatStreamContext(context.Background(), query, onChunk)
+}
 
-	query.Stream = true
+// ChatStreamContext is like ChatStream but the stream is stopped when ctx is done:
+// the request is canceled and ctx.Err() is returned.
+func (c *Client) ChatStreamContext(ctx context.Context, query Query, onChunk func(Answer) error) (Answer, error) {
 
-	jsonQuery, err := json.Marshal(query)
-	if err != nil {
-		return Answer{}, err
-	}
+	query.Stream = true
 
-	req, err := http.NewRequest(http.MethodPost, url+"/api/chat", bytes.NewBuffer(jsonQuery))
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/chat", query, query.TokenHeaderName, query.TokenHeaderValue)
 	if err != nil {
 		return Answer{}, err
 	}
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-
-	if query.TokenHeaderName != "" && query.TokenHeaderValue != "" {
-		req.Header.Set(query.TokenHeaderName, query.TokenHeaderValue)
-	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		if ctx.Err() != nil {
+			return Answer{}, ctx.Err()
+		}
 		return Answer{}, err
 	}
 	defer resp.Body.Close()
-	reader := bufio.NewReader(resp.Body)
 
+	if resp.StatusCode != http.StatusOK {
+		return Answer{}, newAPIError(resp)
+	}
 	var fullAnswer Answer
-	var answer Answer
-	for {
-
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			// we need to create a new error because
-			// because, even if the status is not ok (ex 401 Unauthorized)
-			// the error == nil
-			//return Answer{}, errors.New("Error: status code: " + resp.Status)
-			return Answer{}, errors.New("Error: status code: " + resp.Status)
-		}
-
-		err = json.Unmarshal(line, &answer)
-		if err != nil {
-			onChunk(Answer{})
-		}
+	err = decodeStream(ctx, resp.Body, func(answer Answer) error {
+		fullAnswer.Model = answer.Model
+		fullAnswer.CreatedAt = answer.CreatedAt
+		fullAnswer.Done = answer.Done
+		fullAnswer.Message.Role = answer.Message.Role
 		fullAnswer.Message.Content += answer.Message.Content
-		err = onChunk(answer)
-
-		// generate an error to stop the stream
-		if err != nil {
-			return Answer{}, err
+		if answer.Done {
+			// the metrics are sent with the last chunk
+			fullAnswer.DoneReason = answer.DoneReason
+			fullAnswer.Metrics = answer.Metrics
 		}
+		return onChunk(answer)
+	})
+	if err != nil {
+		return Answer{}, err
 	}
-	fullAnswer.Message.Role = answer.Message.Role
-	//return fullAnswer, nil
-	if resp.StatusCode != http.StatusOK {
-		return Answer{}, errors.New("Error: status code: " + resp.Status)
-	} else {
-		return fullAnswer, nil
-	}
+	return fullAnswer, nil
 
 }
 
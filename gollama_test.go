@@ -1,6 +1,7 @@
 package gollama
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strconv"
@@ -27,9 +28,18 @@ var docs = []string{
 	He's been portrayed by actor Patrick Stewart.`,
 }
 
+// ensureModel pulls the model before the test if it is not on the Ollama server.
+func ensureModel(t *testing.T, ollamaUrl, model string) {
+	t.Helper()
+	if err := EnsureModel(context.Background(), ollamaUrl, model, nil); err != nil {
+		t.Fatal("😡 unable to load the model", model, ":", err)
+	}
+}
+
 func TestEmbeddingCreation(t *testing.T) {
 	ollamaUrl := "http://localhost:11434"
 	model := "all-minilm"
+	ensureModel(t, ollamaUrl, model)
 
 	content := `The best pizza of the world is the pineapple pizza`
 
@@ -56,6 +66,7 @@ func TestVectorStore(t *testing.T) {
 
 	ollamaUrl := "http://localhost:11434"
 	embeddingsModel := "all-minilm:22m"
+	ensureModel(t, ollamaUrl, embeddingsModel)
 
 	store := MemoryVectorStore{
 		Records: make(map[string]VectorRecord),
@@ -99,6 +110,7 @@ func TestSimilaritySearch(t *testing.T) {
 
 	ollamaUrl := "http://localhost:11434"
 	embeddingsModel := "all-minilm:22m"
+	ensureModel(t, ollamaUrl, embeddingsModel)
 
 	store := MemoryVectorStore{
 		Records: make(map[string]VectorRecord),
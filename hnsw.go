@@ -0,0 +1,524 @@
+package gollama
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// === HNSW Vector Store ===
+// Hierarchical Navigable Small World graph for approximate nearest neighbour search
+// https://arxiv.org/abs/1603.09320
+
+// HNSWConfig is the configuration of an HNSWVectorStore.
+type HNSWConfig struct {
+	M              int   // maximum number of connections per node and per layer (2*M on the layer 0)
+	EfConstruction int   // size of the candidates list when inserting a record (quality of the graph)
+	EfSearch       int   // size of the candidates list when searching (quality of the results)
+	Seed           int64 // seed of the random generator used to choose the layers of the nodes
+}
+
+// DefaultHNSWConfig returns the default configuration of an HNSWVectorStore.
+func DefaultHNSWConfig() HNSWConfig {
+	return HNSWConfig{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       100,
+		Seed:           42,
+	}
+}
+
+type hnswNode struct {
+	record    VectorRecord
+	vector    []float64 // normalized embedding: the cosine similarity is the dot product
+	neighbors [][]int   // indexes of the neighbors, for every layer of the node
+	deleted   bool
+}
+
+// HNSWVectorStore is an in-memory vector store indexed with an HNSW graph.
+// SearchTopNSimilarities is an approximate search (much faster than a linear scan on large stores),
+// SearchSimilarities stays an exact linear scan because it returns all the records above the limit.
+//
+// The deleted (or replaced) records are removed from the results but stay in the graph
+// until they are more than the live records: the graph is then rebuilt with the live records only.
+// The embedding model and dimension are recorded on the first insert (see MemoryVectorStore).
+// An HNSWVectorStore is safe for concurrent use.
+type HNSWVectorStore struct {
+	config     HNSWConfig
+	nodes      []*hnswNode
+	ids        map[string]int // id -> index of the node
+	entryPoint int
+	maxLevel   int
+	levelMult  float64
+	random     *rand.Rand
+	spec       embeddingSpec
+	mutex      sync.RWMutex
+}
+
+var _ FilterableVectorStore = (*HNSWVectorStore)(nil)
+var _ MetricVectorStore = (*HNSWVectorStore)(nil)
+
+// NewHNSWVectorStore creates an empty HNSWVectorStore.
+// The zero values of the config are replaced by the values of DefaultHNSWConfig.
+func NewHNSWVectorStore(config HNSWConfig) *HNSWVectorStore {
+	defaultConfig := DefaultHNSWConfig()
+	if config.M <= 1 {
+		config.M = defaultConfig.M
+	}
+	if config.EfConstruction <= 0 {
+		config.EfConstruction = defaultConfig.EfConstruction
+	}
+	if config.EfSearch <= 0 {
+		config.EfSearch = defaultConfig.EfSearch
+	}
+	return &HNSWVectorStore{
+		config:     config,
+		ids:        make(map[string]int),
+		entryPoint: -1,
+		levelMult:  1.0 / math.Log(float64(config.M)),
+		random:     rand.New(rand.NewSource(config.Seed)),
+	}
+}
+
+func normalize(vector []float64) []float64 {
+	norm := math.Sqrt(dotProduct(vector, vector))
+	normalized := make([]float64, len(vector))
+	if norm <= 0.0 {
+		return normalized
+	}
+	for i, value := range vector {
+		normalized[i] = value / norm
+	}
+	return normalized
+}
+
+func (store *HNSWVectorStore) similarity(vector []float64, index int) float64 {
+	return dotProduct(vector, store.nodes[index].vector)
+}
+
+func (store *HNSWVectorStore) maxConnections(level int) int {
+	if level == 0 {
+		return 2 * store.config.M
+	}
+	return store.config.M
+}
+
+func (store *HNSWVectorStore) Get(id string) (VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	index, ok := store.ids[id]
+	if !ok {
+		return VectorRecord{}, recordNotFound(id)
+	}
+	return store.nodes[index].record, nil
+}
+
+func (store *HNSWVectorStore) GetAll() ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	var records []VectorRecord
+	for _, index := range store.ids {
+		records = append(records, store.nodes[index].record)
+	}
+	return records, nil
+}
+
+func (store *HNSWVectorStore) Count() (int, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return len(store.ids), nil
+}
+
+func (store *HNSWVectorStore) Delete(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if index, ok := store.ids[id]; ok {
+		store.nodes[index].deleted = true
+		delete(store.ids, id)
+		store.compactIfNeeded()
+	}
+	return nil
+}
+
+// DeleteWhere deletes the records matching the filter and returns the number of deleted records.
+func (store *HNSWVectorStore) DeleteWhere(filter Filter) (int, error) {
+	if filter == nil {
+		return 0, ErrNilFilter
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	deleted := 0
+	for id, index := range store.ids {
+		if filter(store.nodes[index].record) {
+			store.nodes[index].deleted = true
+			delete(store.ids, id)
+			deleted++
+		}
+	}
+	store.compactIfNeeded()
+	return deleted, nil
+}
+
+// Save inserts the record into the graph.
+// If a record with the same id exists, it is replaced.
+func (store *HNSWVectorStore) Save(vectorRecord VectorRecord) (VectorRecord, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if err := store.spec.check(vectorRecord); err != nil {
+		return VectorRecord{}, err
+	}
+	store.spec.update(vectorRecord)
+
+	var previous VectorRecord
+	index, exists := store.ids[vectorRecord.Id]
+	if exists {
+		previous = store.nodes[index].record
+		store.nodes[index].deleted = true
+		delete(store.ids, vectorRecord.Id)
+	}
+	vectorRecord = nextVersion(vectorRecord, previous, exists)
+	store.insert(vectorRecord)
+	store.compactIfNeeded()
+	return vectorRecord, nil
+}
+
+// hnswMinTombstones is the number of deleted nodes below which the graph is never rebuilt.
+const hnswMinTombstones = 64
+
+// compactIfNeeded rebuilds the graph when the deleted nodes are more than the live nodes,
+// so the graph (and the cost of the searches) does not grow with the updates.
+func (store *HNSWVectorStore) compactIfNeeded() {
+	tombstones := len(store.nodes) - len(store.ids)
+	if tombstones < hnswMinTombstones || tombstones <= len(store.ids) {
+		return
+	}
+	nodes := store.nodes
+	store.nodes = make([]*hnswNode, 0, len(store.ids))
+	store.ids = make(map[string]int, len(store.ids))
+	store.entryPoint = -1
+	store.maxLevel = 0
+	// in the insertion order
+	for _, node := range nodes {
+		if !node.deleted {
+			store.insert(node.record)
+		}
+	}
+}
+
+// insert adds a node for the record to the graph.
+func (store *HNSWVectorStore) insert(vectorRecord VectorRecord) {
+	level := int(math.Floor(-math.Log(1.0-store.random.Float64()) * store.levelMult))
+	node := &hnswNode{
+		record:    vectorRecord,
+		vector:    normalize(vectorRecord.Embedding),
+		neighbors: make([][]int, level+1),
+	}
+	index := len(store.nodes)
+	store.nodes = append(store.nodes, node)
+	store.ids[vectorRecord.Id] = index
+
+	if store.entryPoint < 0 {
+		store.entryPoint = index
+		store.maxLevel = level
+		return
+	}
+
+	entryPoint := store.entryPoint
+	for layer := store.maxLevel; layer > level; layer-- {
+		entryPoint = store.greedySearch(node.vector, entryPoint, layer)
+	}
+
+	entryPoints := []int{entryPoint}
+	for layer := min(level, store.maxLevel); layer >= 0; layer-- {
+		candidates := store.searchLayer(node.vector, entryPoints, store.config.EfConstruction, layer)
+
+		node.neighbors[layer] = store.selectNeighbors(candidates, store.config.M)
+		for _, neighbor := range node.neighbors[layer] {
+			store.connect(neighbor, index, layer)
+		}
+
+		entryPoints = entryPoints[:0]
+		for _, candidate := range candidates {
+			entryPoints = append(entryPoints, candidate.index)
+		}
+	}
+
+	if level > store.maxLevel {
+		store.entryPoint = index
+		store.maxLevel = level
+	}
+}
+
+// connect adds a link from the node "from" to the node "to" on the layer,
+// and selects again the neighbors of "from" if it has too many connections.
+func (store *HNSWVectorStore) connect(from, to, layer int) {
+	node := store.nodes[from]
+	node.neighbors[layer] = append(node.neighbors[layer], to)
+
+	maxConnections := store.maxConnections(layer)
+	if len(node.neighbors[layer]) <= maxConnections {
+		return
+	}
+	candidates := make([]hnswCandidate, len(node.neighbors[layer]))
+	for i, neighbor := range node.neighbors[layer] {
+		candidates[i] = hnswCandidate{index: neighbor, similarity: store.similarity(node.vector, neighbor)}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+	node.neighbors[layer] = store.selectNeighbors(candidates, maxConnections)
+}
+
+// selectNeighbors selects at most m neighbors from the candidates (sorted, the most similar first)
+// with the heuristic of the HNSW paper: a candidate is selected only if it is more similar
+// to the inserted node than to the already selected neighbors, so the links go in several directions.
+// The list is completed with the most similar discarded candidates.
+func (store *HNSWVectorStore) selectNeighbors(candidates []hnswCandidate, m int) []int {
+	selected := make([]int, 0, m)
+	var discarded []int
+	for _, candidate := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		keep := true
+		for _, neighbor := range selected {
+			if store.similarity(store.nodes[candidate.index].vector, neighbor) > candidate.similarity {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, candidate.index)
+		} else {
+			discarded = append(discarded, candidate.index)
+		}
+	}
+	for _, index := range discarded {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, index)
+	}
+	return selected
+}
+
+// greedySearch returns the closest node to vector on the layer, starting from entryPoint.
+func (store *HNSWVectorStore) greedySearch(vector []float64, entryPoint, layer int) int {
+	current := entryPoint
+	currentSimilarity := store.similarity(vector, current)
+	for changed := true; changed; {
+		changed = false
+		for _, neighbor := range store.nodes[current].neighbors[layer] {
+			if similarity := store.similarity(vector, neighbor); similarity > currentSimilarity {
+				current, currentSimilarity = neighbor, similarity
+				changed = true
+			}
+		}
+	}
+	return current
+}
+
+// searchLayer returns the ef closest nodes to vector on the layer (the most similar first).
+// The deleted nodes are kept: they are still used to navigate the graph.
+func (store *HNSWVectorStore) searchLayer(vector []float64, entryPoints []int, ef, layer int) []hnswCandidate {
+	visited := make([]bool, len(store.nodes))
+	candidates := &hnswHeap{} // the most similar on top
+	results := &hnswHeap{worstFirst: true}
+
+	for _, entryPoint := range entryPoints {
+		if visited[entryPoint] {
+			continue
+		}
+		visited[entryPoint] = true
+		candidate := hnswCandidate{index: entryPoint, similarity: store.similarity(vector, entryPoint)}
+		heap.Push(candidates, candidate)
+		heap.Push(results, candidate)
+	}
+	for results.Len() > ef {
+		heap.Pop(results)
+	}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && current.similarity < results.items[0].similarity {
+			break
+		}
+		for _, neighbor := range store.nodes[current.index].neighbors[layer] {
+			if visited[neighbor] {
+				continue
+			}
+			visited[neighbor] = true
+			similarity := store.similarity(vector, neighbor)
+			if results.Len() < ef || similarity > results.items[0].similarity {
+				candidate := hnswCandidate{index: neighbor, similarity: similarity}
+				heap.Push(candidates, candidate)
+				heap.Push(results, candidate)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sorted := results.items
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].similarity > sorted[j].similarity
+	})
+	return sorted
+}
+
+// SearchSimilarities returns all the records with a cosine similarity
+// greater than or equal to limit (exact linear scan).
+func (store *HNSWVectorStore) SearchSimilarities(embeddingFromQuestion VectorRecord, limit float64) ([]VectorRecord, error) {
+	return store.SearchSimilaritiesWithFilter(embeddingFromQuestion, limit, nil)
+}
+
+// SearchSimilaritiesWithFilter is like SearchSimilarities,
+// but only the records matching the filter are compared (a nil filter matches all the records).
+func (store *HNSWVectorStore) SearchSimilaritiesWithFilter(embeddingFromQuestion VectorRecord, limit float64, filter Filter) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	if err := store.spec.check(embeddingFromQuestion); err != nil {
+		return nil, err
+	}
+
+	vector := normalize(embeddingFromQuestion.Embedding)
+	var records []VectorRecord
+	for _, index := range store.ids {
+		if filter != nil && !filter(store.nodes[index].record) {
+			continue
+		}
+		similarity := store.similarity(vector, index)
+		if similarity >= limit {
+			record := store.nodes[index].record
+			record.CosineDistance = similarity
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// SearchTopNSimilarities returns (approximately) the max most similar records
+// with a cosine similarity greater than or equal to limit, the most similar first.
+func (store *HNSWVectorStore) SearchTopNSimilarities(embeddingFromQuestion VectorRecord, limit float64, max int) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	if err := store.spec.check(embeddingFromQuestion); err != nil {
+		return nil, err
+	}
+
+	if store.entryPoint < 0 || max <= 0 {
+		return nil, nil
+	}
+
+	vector := normalize(embeddingFromQuestion.Embedding)
+	entryPoint := store.entryPoint
+	for layer := store.maxLevel; layer > 0; layer-- {
+		entryPoint = store.greedySearch(vector, entryPoint, layer)
+	}
+	// the deleted nodes are in the results of searchLayer:
+	// search more candidates to still get max records, at most twice as many
+	// (the graph is rebuilt before the deleted nodes are more than the live nodes)
+	ef := store.config.EfSearch
+	if ef < max {
+		ef = max
+	}
+	if tombstones := len(store.nodes) - len(store.ids); tombstones > 0 {
+		ef = min(ef+tombstones, 2*ef)
+	}
+
+	var records []VectorRecord
+	for _, candidate := range store.searchLayer(vector, []int{entryPoint}, ef, 0) {
+		node := store.nodes[candidate.index]
+		if node.deleted || candidate.similarity < limit {
+			continue
+		}
+		record := node.record
+		record.CosineDistance = candidate.similarity
+		records = append(records, record)
+		if len(records) == max {
+			break
+		}
+	}
+	return records, nil
+}
+
+// SearchTopNSimilaritiesWithFilter is like SearchTopNSimilarities,
+// but only the records matching the filter are compared (a nil filter matches all the records).
+// The filter is applied before ranking, so the search is an exact linear scan
+// over the matching records (a graph search could miss them).
+func (store *HNSWVectorStore) SearchTopNSimilaritiesWithFilter(embeddingFromQuestion VectorRecord, limit float64, max int, filter Filter) ([]VectorRecord, error) {
+	if filter == nil {
+		return store.SearchTopNSimilarities(embeddingFromQuestion, limit, max)
+	}
+	records, err := store.SearchSimilaritiesWithFilter(embeddingFromQuestion, limit, filter)
+	if err != nil {
+		return nil, err
+	}
+	return getTopNVectorRecords(records, max), nil
+}
+
+type hnswCandidate struct {
+	index      int
+	similarity float64
+}
+
+// hnswHeap is a heap of candidates, the most similar on top
+// (or the least similar on top if worstFirst is true).
+type hnswHeap struct {
+	items      []hnswCandidate
+	worstFirst bool
+}
+
+func (h *hnswHeap) Len() int { return len(h.items) }
+func (h *hnswHeap) Less(i, j int) bool {
+	if h.worstFirst {
+		return h.items[i].similarity < h.items[j].similarity
+	}
+	return h.items[i].similarity > h.items[j].similarity
+}
+func (h *hnswHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *hnswHeap) Push(x interface{}) { h.items = append(h.items, x.(hnswCandidate)) }
+func (h *hnswHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// SearchSimilaritiesWithMetric is like MemoryVectorStore.SearchSimilaritiesWithMetric
+// (an exact linear scan: the graph is built for the cosine similarity).
+func (store *HNSWVectorStore) SearchSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, metric Metric) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	if metric.comparesEmbeddings() {
+		if err := store.spec.check(embeddingFromQuestion); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []VectorRecord
+	for _, index := range store.ids {
+		record := store.nodes[index].record
+		score := metric.Score(embeddingFromQuestion, record)
+		if metric.Passes(score, limit) {
+			record.Score = score
+			if metric == CosineMetric {
+				record.CosineDistance = score
+			}
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// SearchTopNSimilaritiesWithMetric is like SearchSimilaritiesWithMetric,
+// but returns at most max records, the most similar first.
+func (store *HNSWVectorStore) SearchTopNSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, max int, metric Metric) ([]VectorRecord, error) {
+	records, err := store.SearchSimilaritiesWithMetric(embeddingFromQuestion, limit, metric)
+	if err != nil {
+		return nil, err
+	}
+	return sortByScore(records, metric, max), nil
+}
@@ -0,0 +1,160 @@
+package gollama
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func randomRecords(random *rand.Rand, count, dimension int) []VectorRecord {
+	records := make([]VectorRecord, count)
+	for idx := range records {
+		embedding := make([]float64, dimension)
+		for i := range embedding {
+			embedding[i] = random.NormFloat64()
+		}
+		records[idx] = VectorRecord{Id: strconv.Itoa(idx), Embedding: embedding}
+	}
+	return records
+}
+
+// recall returns the proportion of the exact top N records found by the approximate search
+func recall(exact, approximate []VectorRecord) float64 {
+	found := map[string]bool{}
+	for _, record := range approximate {
+		found[record.Id] = true
+	}
+	hits := 0
+	for _, record := range exact {
+		if found[record.Id] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(exact))
+}
+
+func newHNSWBenchmarkStores(count, dimension int) (*MemoryVectorStore, *HNSWVectorStore, []VectorRecord) {
+	random := rand.New(rand.NewSource(1))
+	bruteForce := NewMemoryVectorStore()
+	hnsw := NewHNSWVectorStore(DefaultHNSWConfig())
+	for _, record := range randomRecords(random, count, dimension) {
+		bruteForce.Save(record)
+		hnsw.Save(record)
+	}
+	return bruteForce, hnsw, randomRecords(random, 50, dimension)
+}
+
+func TestHNSWRecall(t *testing.T) {
+	bruteForce, hnsw, questions := newHNSWBenchmarkStores(3000, 32)
+
+	total := 0.0
+	for _, question := range questions {
+		exact, _ := bruteForce.SearchTopNSimilarities(question, -1.0, 10)
+		approximate, _ := hnsw.SearchTopNSimilarities(question, -1.0, 10)
+		if len(approximate) != 10 {
+			t.Fatal("😡 expected 10 records, got:", len(approximate))
+		}
+		total += recall(exact, approximate)
+	}
+	if average := total / float64(len(questions)); average < 0.95 {
+		t.Fatal("😡 recall too low:", average)
+	}
+}
+
+func TestHNSWDeleteAndReplace(t *testing.T) {
+	hnsw := NewHNSWVectorStore(HNSWConfig{M: 4})
+	for _, record := range randomRecords(rand.New(rand.NewSource(2)), 200, 8) {
+		hnsw.Save(record)
+	}
+	question := VectorRecord{Embedding: []float64{1, 0, 0, 0, 0, 0, 0, 0}}
+
+	top, _ := hnsw.SearchTopNSimilarities(question, -1.0, 1)
+	hnsw.Delete(top[0].Id)
+	hnsw.Save(VectorRecord{Id: "5", Embedding: question.Embedding})
+
+	results, _ := hnsw.SearchTopNSimilarities(question, -1.0, 5)
+	if len(results) != 5 || results[0].Id != "5" {
+		t.Fatal("😡 unexpected results:", results)
+	}
+	for _, record := range results[1:] {
+		if record.Id == top[0].Id || record.Id == "5" {
+			t.Fatal("😡 a deleted or replaced record is returned:", record.Id)
+		}
+	}
+	if count, _ := hnsw.Count(); count != 199 {
+		t.Fatal("😡 unexpected count:", count)
+	}
+}
+
+// updateRepeatedly saves the records again and again, with deletes, to leave tombstones in the graph.
+func updateRepeatedly(hnsw *HNSWVectorStore, records []VectorRecord, rounds int) {
+	for round := 0; round < rounds; round++ {
+		for _, record := range records {
+			hnsw.Save(record)
+		}
+		hnsw.Delete(records[round%len(records)].Id)
+		hnsw.Save(records[round%len(records)])
+	}
+}
+
+func TestHNSWRepeatedUpdates(t *testing.T) {
+	random := rand.New(rand.NewSource(3))
+	records := randomRecords(random, 100, 8)
+	bruteForce := NewMemoryVectorStore()
+	hnsw := NewHNSWVectorStore(HNSWConfig{M: 8, EfConstruction: 50})
+	for _, record := range records {
+		bruteForce.Save(record)
+	}
+	updateRepeatedly(hnsw, records, 50)
+
+	// the deleted nodes are removed from the graph
+	if count, _ := hnsw.Count(); count != 100 || len(hnsw.nodes) > 2*count+hnswMinTombstones {
+		t.Fatal("😡 the graph grows with the updates:", len(hnsw.nodes), "nodes for", count, "records")
+	}
+
+	total := 0.0
+	questions := randomRecords(random, 20, 8)
+	for _, question := range questions {
+		exact, _ := bruteForce.SearchTopNSimilarities(question, -1.0, 5)
+		approximate, _ := hnsw.SearchTopNSimilarities(question, -1.0, 5)
+		total += recall(exact, approximate)
+	}
+	if total/float64(len(questions)) < 0.9 {
+		t.Fatal("😡 recall too low after the updates:", total/float64(len(questions)))
+	}
+}
+
+func BenchmarkSearchTopNBruteForce(b *testing.B) {
+	bruteForce, _, questions := newHNSWBenchmarkStores(10000, 64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bruteForce.SearchTopNSimilarities(questions[i%len(questions)], -1.0, 10)
+	}
+}
+
+func BenchmarkSearchTopNHNSW(b *testing.B) {
+	bruteForce, hnsw, questions := newHNSWBenchmarkStores(10000, 64)
+	total := 0.0
+	for _, question := range questions {
+		exact, _ := bruteForce.SearchTopNSimilarities(question, -1.0, 10)
+		approximate, _ := hnsw.SearchTopNSimilarities(question, -1.0, 10)
+		total += recall(exact, approximate)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hnsw.SearchTopNSimilarities(questions[i%len(questions)], -1.0, 10)
+	}
+	b.ReportMetric(total/float64(len(questions)), "recall")
+}
+
+func BenchmarkSearchTopNHNSWAfterUpdates(b *testing.B) {
+	random := rand.New(rand.NewSource(3))
+	records := randomRecords(random, 100, 8)
+	questions := randomRecords(random, 50, 8)
+	hnsw := NewHNSWVectorStore(HNSWConfig{M: 8, EfConstruction: 50})
+	updateRepeatedly(hnsw, records, 200)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hnsw.SearchTopNSimilarities(questions[i%len(questions)], -1.0, 5)
+	}
+}
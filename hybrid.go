@@ -0,0 +1,187 @@
+package gollama
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// === Hybrid search ===
+
+// Fusion is the way to combine the keyword (BM25) and the vector (cosine) rankings.
+type Fusion int
+
+const (
+	// ReciprocalRankFusion sums weight / (k + rank) for every ranking:
+	// only the ranks are used, so the scores do not need to be comparable.
+	ReciprocalRankFusion Fusion = iota
+	// WeightedSumFusion sums weight * score for every ranking,
+	// the BM25 scores being divided by the best BM25 score (between 0 and 1).
+	WeightedSumFusion
+)
+
+// HybridOptions configures a hybrid search.
+type HybridOptions struct {
+	Fusion        Fusion
+	KeywordWeight float64 // weight of the BM25 ranking (0.5 by default)
+	VectorWeight  float64 // weight of the cosine ranking (0.5 by default)
+	RRFK          int     // k constant of the reciprocal rank fusion (60 by default)
+	Candidates    int     // number of candidates taken from each ranking (4 * max by default)
+}
+
+// HybridVectorStore is a VectorStore with a BM25Index maintained alongside:
+// the records saved or deleted through the HybridVectorStore are (un)indexed.
+//
+// A HybridVectorStore is safe for concurrent use: a write to the store
+// and the update of the index are done together.
+type HybridVectorStore struct {
+	VectorStore
+	Index *BM25Index
+	mutex sync.RWMutex // between the writes (store + index) and the hybrid searches
+}
+
+var _ VectorStore = (*HybridVectorStore)(nil)
+
+// NewHybridVectorStore wraps the store and indexes its current records.
+func NewHybridVectorStore(store VectorStore) (*HybridVectorStore, error) {
+	hybrid := &HybridVectorStore{
+		VectorStore: store,
+		Index:       NewBM25Index(),
+	}
+	records, err := store.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		hybrid.Index.Add(record)
+	}
+	return hybrid, nil
+}
+
+func (store *HybridVectorStore) Save(vectorRecord VectorRecord) (VectorRecord, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	record, err := store.VectorStore.Save(vectorRecord)
+	if err != nil {
+		return VectorRecord{}, err
+	}
+	store.Index.Add(record)
+	return record, nil
+}
+
+func (store *HybridVectorStore) Delete(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if err := store.VectorStore.Delete(id); err != nil {
+		return err
+	}
+	store.Index.Remove(id)
+	return nil
+}
+
+// DeleteWhere deletes the records matching the filter from the wrapped store
+// (it must be a FilterableVectorStore) and from the index,
+// and returns the number of deleted records.
+func (store *HybridVectorStore) DeleteWhere(filter Filter) (int, error) {
+	if filter == nil {
+		return 0, ErrNilFilter
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	filterable, ok := store.VectorStore.(FilterableVectorStore)
+	if !ok {
+		return 0, fmt.Errorf("Error: DeleteWhere is not supported by the store (%T)", store.VectorStore)
+	}
+	var ids []string
+	deleted, err := filterable.DeleteWhere(func(record VectorRecord) bool {
+		if filter(record) {
+			ids = append(ids, record.Id)
+			return true
+		}
+		return false
+	})
+	for _, id := range ids {
+		// after an error, only the records no longer in the store are removed from the index
+		if err != nil {
+			if _, getErr := store.VectorStore.Get(id); !errors.Is(getErr, ErrRecordNotFound) {
+				continue
+			}
+		}
+		store.Index.Remove(id)
+	}
+	return deleted, err
+}
+
+// HybridSearch searches with both the keywords of queryText (BM25)
+// and the embedding of the question (cosine similarity),
+// and returns at most max records ranked by the fusion of the two rankings.
+// The Score field of the records is the fused score,
+// the CosineDistance field is the cosine similarity with the question.
+func (store *HybridVectorStore) HybridSearch(queryText string, embeddingFromQuestion VectorRecord, max int, options HybridOptions) ([]VectorRecord, error) {
+	if options.KeywordWeight == 0 && options.VectorWeight == 0 {
+		options.KeywordWeight = 0.5
+		options.VectorWeight = 0.5
+	}
+	if options.RRFK <= 0 {
+		options.RRFK = 60
+	}
+	if options.Candidates <= 0 {
+		options.Candidates = 4 * max
+	}
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	vectorResults, err := store.SearchTopNSimilarities(embeddingFromQuestion, -1.0, options.Candidates)
+	if err != nil {
+		return nil, err
+	}
+	keywordResults := store.Index.Search(queryText, options.Candidates)
+
+	records := map[string]VectorRecord{}
+	scores := map[string]float64{}
+
+	for rank, record := range vectorResults {
+		records[record.Id] = record
+		if options.Fusion == WeightedSumFusion {
+			scores[record.Id] += options.VectorWeight * record.CosineDistance
+		} else {
+			scores[record.Id] += options.VectorWeight / float64(options.RRFK+rank+1)
+		}
+	}
+
+	for rank, result := range keywordResults {
+		if _, ok := records[result.Id]; !ok {
+			record, err := store.Get(result.Id)
+			if err != nil {
+				return nil, err
+			}
+			record.CosineDistance = CosineSimilarity(embeddingFromQuestion.Embedding, record.Embedding)
+			records[result.Id] = record
+			if options.Fusion == WeightedSumFusion {
+				scores[result.Id] += options.VectorWeight * record.CosineDistance
+			}
+		}
+		if options.Fusion == WeightedSumFusion {
+			scores[result.Id] += options.KeywordWeight * result.Score / keywordResults[0].Score
+		} else {
+			scores[result.Id] += options.KeywordWeight / float64(options.RRFK+rank+1)
+		}
+	}
+
+	results := make([]VectorRecord, 0, len(records))
+	for id, record := range records {
+		record.Score = scores[id]
+		results = append(results, record)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Id < results[j].Id
+		}
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > max {
+		results = results[:max]
+	}
+	return results, nil
+}
@@ -0,0 +1,116 @@
+package gollama
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestBM25Index(t *testing.T) {
+	index := NewBM25Index()
+	index.Add(VectorRecord{Id: "1", Text: "The warp core breach is fixed in ticket JIRA-1234"})
+	index.Add(VectorRecord{Id: "2", Text: "The warp drive uses dilithium crystals"})
+	index.Add(VectorRecord{Id: "3", Text: "Call os.ReadFile to load the captain's log"})
+
+	results := index.Search("JIRA-1234", 10)
+	if len(results) != 1 || results[0].Id != "1" {
+		t.Fatal("😡 unexpected results:", results)
+	}
+	results = index.Search("os.ReadFile", 10)
+	if len(results) != 1 || results[0].Id != "3" {
+		t.Fatal("😡 unexpected results:", results)
+	}
+	// the rare term "dilithium" is more important than "warp"
+	results = index.Search("warp dilithium", 10)
+	if len(results) != 2 || results[0].Id != "2" {
+		t.Fatal("😡 unexpected results:", results)
+	}
+
+	index.Remove("2")
+	index.Add(VectorRecord{Id: "1", Text: "nothing to see"})
+	if results := index.Search("warp", 10); len(results) != 0 {
+		t.Fatal("😡 the records should be removed:", results)
+	}
+}
+
+func TestHybridSearch(t *testing.T) {
+	store, err := NewHybridVectorStore(NewMemoryVectorStore())
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	store.Save(VectorRecord{Id: "ticket", Text: "Fix for ticket JIRA-1234 in the transporter buffer", Embedding: []float64{0.0, 1.0}})
+	store.Save(VectorRecord{Id: "transporter", Text: "How the transporter works", Embedding: []float64{1.0, 0.1}})
+	store.Save(VectorRecord{Id: "holodeck", Text: "The holodeck safety protocols", Embedding: []float64{0.9, 0.5}})
+	store.Save(VectorRecord{Id: "deleted", Text: "JIRA-1234 duplicate", Embedding: []float64{0.0, 1.0}})
+	store.Delete("deleted")
+
+	// the embedding of the question is far from the ticket, but the keyword matches
+	question := VectorRecord{Embedding: []float64{1.0, 0.0}}
+
+	for _, fusion := range []Fusion{ReciprocalRankFusion, WeightedSumFusion} {
+		results, err := store.HybridSearch("What is JIRA-1234?", question, 2, HybridOptions{
+			Fusion:        fusion,
+			KeywordWeight: 0.7,
+			VectorWeight:  0.3,
+		})
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		if len(results) != 2 || results[0].Id != "ticket" || results[1].Id != "transporter" {
+			t.Fatal("😡 unexpected results:", fusion, results)
+		}
+		if results[0].CosineDistance != 0.0 || results[1].CosineDistance == 0.0 {
+			t.Fatal("😡 the cosine similarity should be set:", results)
+		}
+	}
+
+	// only the vector ranking
+	results, _ := store.HybridSearch("What is JIRA-1234?", question, 1, HybridOptions{VectorWeight: 1.0})
+	if results[0].Id != "transporter" {
+		t.Fatal("😡 unexpected results:", results)
+	}
+}
+
+func TestHybridDeleteWhere(t *testing.T) {
+	store, _ := NewHybridVectorStore(NewMemoryVectorStore())
+	store.Save(VectorRecord{Id: "ticket", Text: "Fix for ticket JIRA-1234", Embedding: []float64{0.0, 1.0}, Attributes: map[string]interface{}{"status": "closed"}})
+	store.Save(VectorRecord{Id: "duplicate", Text: "JIRA-1234 duplicate", Embedding: []float64{0.0, 1.0}, Attributes: map[string]interface{}{"status": "open"}})
+
+	if _, err := store.DeleteWhere(nil); !errors.Is(err, ErrNilFilter) {
+		t.Fatal("😡 a nil filter should be rejected:", err)
+	}
+	deleted, err := store.DeleteWhere(Eq("status", "closed"))
+	if err != nil || deleted != 1 {
+		t.Fatal("😡 unexpected result:", deleted, err)
+	}
+	results := store.Index.Search("JIRA-1234", 10)
+	if len(results) != 1 || results[0].Id != "duplicate" {
+		t.Fatal("😡 the deleted record is still indexed:", results)
+	}
+
+	// the wrapped store has no DeleteWhere
+	unfilterable, _ := NewHybridVectorStore(struct{ VectorStore }{NewMemoryVectorStore()})
+	if _, err := unfilterable.DeleteWhere(Eq("status", "closed")); err == nil {
+		t.Fatal("😡 expected an error")
+	}
+}
+
+func TestHybridConcurrentSaves(t *testing.T) {
+	store, _ := NewHybridVectorStore(NewMemoryVectorStore())
+	var wait sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			store.Save(VectorRecord{Id: "log", Text: "stardate" + strconv.Itoa(i), Embedding: []float64{1.0, 0.0}})
+		}(i)
+	}
+	wait.Wait()
+
+	// the index has the text of the last saved record
+	record, _ := store.Get("log")
+	if results := store.Index.Search(record.Text, 10); len(results) != 1 || results[0].Id != "log" {
+		t.Fatal("😡 the index is not in sync with the store:", record.Text, results)
+	}
+}
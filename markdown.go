@@ -0,0 +1,274 @@
+package gollama
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// === Markdown splitter ===
+
+// MarkdownChunk is a chunk of a Markdown document with the hierarchy of its headings
+// (ex: ["Install", "Linux"] for a chunk of the "## Linux" section of the "# Install" section).
+type MarkdownChunk struct {
+	Chunk
+	Headings []string `json:"headings,omitempty"`
+}
+
+// HeadingPath returns the hierarchy of the headings of the chunk (ex: "Install > Linux"),
+// to be stored in the MetaData of the VectorRecord and shown as a citation:
+//
+//	for idx, chunk := range gollama.SplitMarkdown(document, 500, 50) {
+//		record, err := gollama.CreateEmbedding(ollamaUrl, gollama.Query4Embedding{Model: model, Prompt: chunk.Text}, strconv.Itoa(idx))
+//		...
+//		record.Text = chunk.Text
+//		record.MetaData = chunk.HeadingPath()
+//		store.Save(record)
+//	}
+func (chunk MarkdownChunk) HeadingPath() string {
+	return strings.Join(chunk.Headings, " > ")
+}
+
+// MarkdownTextSplitter splits a Markdown document into chunks of at most ChunkSize characters.
+//
+// The document is parsed into headings (ATX headings: "# Title"), fenced code blocks
+// (``` or ~~~), lists, tables and paragraphs:
+//   - a chunk never crosses a heading, the headings are not in the text of the chunks
+//     but in their Headings;
+//   - a fenced code block is never split (a chunk with a code block longer than ChunkSize
+//     is longer than ChunkSize);
+//   - the lists and the tables are split between their lines, the paragraphs like
+//     a RecursiveTextSplitter (lines, sentences, words);
+//   - the consecutive blocks of a section are merged as long as the chunk is not too long.
+type MarkdownTextSplitter struct {
+	ChunkSize    int // maximum length of a chunk, in characters (DefaultChunkSize by default)
+	ChunkOverlap int // maximum length of the overlap, in characters (limited to ChunkSize / 2)
+}
+
+// SplitMarkdown splits the Markdown document with a MarkdownTextSplitter.
+func SplitMarkdown(document string, chunkSize, chunkOverlap int) []MarkdownChunk {
+	splitter := MarkdownTextSplitter{ChunkSize: chunkSize, ChunkOverlap: chunkOverlap}
+	return splitter.Split(document)
+}
+
+type markdownBlockKind int
+
+const (
+	markdownParagraph markdownBlockKind = iota
+	markdownHeading
+	markdownFence
+	markdownList
+	markdownTable
+)
+
+// markdownBlock is a block of a document: document[start:end], with the blank lines after it.
+type markdownBlock struct {
+	kind       markdownBlockKind
+	start, end int
+	level      int    // of a heading
+	title      string // of a heading
+	fences     []span // the fenced code blocks of the items of a list
+}
+
+var (
+	markdownHeadingLine = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	markdownFenceLine   = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	markdownListLine    = regexp.MustCompile(`^ {0,3}(?:[-*+]|\d{1,9}[.)])(?:[ \t]|$)`)
+	markdownTableLine   = regexp.MustCompile(`^ {0,3}\|`)
+	// a fence in a list item: indented, or after the marker of the item
+	markdownListFenceLine = regexp.MustCompile("^[ \t]*(?:(?:[-*+]|\\d{1,9}[.)])[ \t]+)?(`{3,}|~{3,})")
+)
+
+// Split returns the chunks of the document, with the spaces around them trimmed.
+func (splitter MarkdownTextSplitter) Split(document string) []MarkdownChunk {
+	size := splitter.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	overlap := min(max(splitter.ChunkOverlap, 0), size/2)
+
+	var chunks []MarkdownChunk
+	var headings []string
+	var levels []int
+	var parts []span
+
+	flush := func() {
+		for _, chunk := range mergeSpans(document, parts, size, overlap) {
+			chunks = append(chunks, MarkdownChunk{Chunk: chunk, Headings: headings})
+		}
+		parts = nil
+	}
+
+	for _, block := range parseMarkdown(document) {
+		current := span{block.start, block.end, utf8.RuneCountInString(document[block.start:block.end])}
+		switch block.kind {
+		case markdownHeading:
+			flush()
+			// the headings of the same or a lower level are closed
+			for len(levels) > 0 && levels[len(levels)-1] >= block.level {
+				levels = levels[:len(levels)-1]
+				headings = headings[:len(headings)-1]
+			}
+			// a new slice, the previous chunks keep their headings
+			headings = append(append([]string(nil), headings...), block.title)
+			levels = append(levels, block.level)
+		case markdownFence:
+			parts = append(parts, current)
+		case markdownList:
+			parts = splitList(document, block, size, parts)
+		case markdownTable:
+			parts = splitSpan(document, current, DefaultSeparators[1:], size, parts)
+		default:
+			parts = splitSpan(document, current, DefaultSeparators, size, parts)
+		}
+	}
+	flush()
+	return chunks
+}
+
+// parseMarkdown splits the document into contiguous blocks.
+func parseMarkdown(document string) []markdownBlock {
+	// the offsets of the lines (with their newline)
+	var lines []span
+	for start := 0; start < len(document); {
+		end := len(document)
+		if i := strings.IndexByte(document[start:], '\n'); i >= 0 {
+			end = start + i + 1
+		}
+		lines = append(lines, span{start: start, end: end})
+		start = end
+	}
+	line := func(i int) string {
+		return strings.TrimRight(document[lines[i].start:lines[i].end], "\r\n")
+	}
+	isBlank := func(i int) bool {
+		return strings.TrimSpace(line(i)) == ""
+	}
+
+	var blocks []markdownBlock
+	for i := 0; i < len(lines); {
+		block := markdownBlock{start: lines[i].start}
+		if len(blocks) > 0 {
+			block.start = blocks[len(blocks)-1].end
+		}
+		// the blank lines before the first block
+		for i < len(lines) && isBlank(i) {
+			i++
+		}
+		if i == len(lines) {
+			if len(blocks) > 0 {
+				blocks[len(blocks)-1].end = len(document)
+			}
+			break
+		}
+
+		text := line(i)
+		switch {
+		case markdownFenceLine.MatchString(text):
+			block.kind = markdownFence
+			marker := markdownFenceLine.FindStringSubmatch(text)[1]
+			// until the closing fence (same character, at least as long), or the end of the document
+			for i++; i < len(lines); i++ {
+				if isClosingFence(line(i), marker) {
+					i++
+					break
+				}
+			}
+		case markdownHeadingLine.MatchString(text):
+			match := markdownHeadingLine.FindStringSubmatch(text)
+			block.kind = markdownHeading
+			block.level = len(match[1])
+			block.title = strings.TrimSpace(match[2])
+			i++
+		case markdownTableLine.MatchString(text):
+			block.kind = markdownTable
+			for i++; i < len(lines) && markdownTableLine.MatchString(line(i)); i++ {
+			}
+		case markdownListLine.MatchString(text):
+			block.kind = markdownList
+			// the marker of the open fenced code block of an item (indented, or after the marker of the item):
+			// its lines are in the list, even the fences and the headings
+			var marker string
+			openFence := func(i int) {
+				if match := markdownListFenceLine.FindStringSubmatch(line(i)); match != nil {
+					marker = match[1]
+					block.fences = append(block.fences, span{start: lines[i].start, end: lines[i].end})
+				}
+			}
+			openFence(i)
+			// the items and their indented continuation lines, even after a blank line
+			for i++; i < len(lines); i++ {
+				if marker != "" {
+					block.fences[len(block.fences)-1].end = lines[i].end
+					if isClosingFence(line(i), marker) {
+						marker = ""
+					}
+					continue
+				}
+				if isBlank(i) {
+					next := i
+					for next < len(lines) && isBlank(next) {
+						next++
+					}
+					if next == len(lines) || !(markdownListLine.MatchString(line(next)) || isIndented(line(next))) {
+						break
+					}
+					i = next
+				}
+				if markdownFenceLine.MatchString(line(i)) || markdownHeadingLine.MatchString(line(i)) {
+					break
+				}
+				openFence(i)
+			}
+		default:
+			block.kind = markdownParagraph
+			for i++; i < len(lines) && !isBlank(i); i++ {
+				text := line(i)
+				if markdownFenceLine.MatchString(text) || markdownHeadingLine.MatchString(text) ||
+					markdownTableLine.MatchString(text) || markdownListLine.MatchString(text) {
+					break
+				}
+			}
+		}
+
+		// the blank lines after the block
+		for i < len(lines) && isBlank(i) {
+			i++
+		}
+		block.end = len(document)
+		if i < len(lines) {
+			block.end = lines[i].start
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// splitList appends the parts of a list to parts: the list is split between its lines,
+// except the fenced code blocks of its items, which are never split.
+func splitList(document string, block markdownBlock, size int, parts []span) []span {
+	appendSpan := func(start, end int, separators []string) {
+		if start < end {
+			parts = splitSpan(document, span{start, end, utf8.RuneCountInString(document[start:end])}, separators, size, parts)
+		}
+	}
+	start := block.start
+	for _, fence := range block.fences {
+		appendSpan(start, fence.start, DefaultSeparators[1:])
+		appendSpan(fence.start, fence.end, nil)
+		start = fence.end
+	}
+	appendSpan(start, block.end, DefaultSeparators[1:])
+	return parts
+}
+
+// isClosingFence reports whether the line closes a fenced code block opened with marker
+// (same character, at least as long).
+func isClosingFence(line, marker string) bool {
+	closing := strings.TrimSpace(line)
+	return strings.HasPrefix(closing, marker) && strings.Trim(closing, marker[:1]) == ""
+}
+
+func isIndented(line string) bool {
+	return strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")
+}
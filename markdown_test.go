@@ -0,0 +1,121 @@
+package gollama
+
+import (
+	"strings"
+	"testing"
+)
+
+const markdownDocument = "# Install\n" +
+	"\n" +
+	"Gollama is a Go module.\n" +
+	"\n" +
+	"## Linux\n" +
+	"\n" +
+	"Run the following commands:\n" +
+	"\n" +
+	"```bash\n" +
+	"# this is not a heading\n" +
+	"go get github.com/parakeet-nest/gollama\n" +
+	"\n" +
+	"go mod tidy\n" +
+	"```\n" +
+	"\n" +
+	"- first item\n" +
+	"- second item\n" +
+	"  with a continuation\n" +
+	"\n" +
+	"## macOS ##\n" +
+	"\n" +
+	"| OS    | Arch  |\n" +
+	"|-------|-------|\n" +
+	"| macOS | arm64 |\n" +
+	"\n" +
+	"# Usage\n" +
+	"\n" +
+	"Create a client.\n"
+
+func TestSplitMarkdown(t *testing.T) {
+	chunks := SplitMarkdown(markdownDocument, 1000, 0)
+
+	expected := []struct {
+		path   string
+		prefix string
+	}{
+		{"Install", "Gollama is a Go module."},
+		{"Install > Linux", "Run the following commands:"},
+		{"Install > macOS", "| OS    | Arch  |"},
+		{"Usage", "Create a client."},
+	}
+	if len(chunks) != len(expected) {
+		t.Fatal("😡 unexpected chunks:", chunks)
+	}
+	for idx, chunk := range chunks {
+		if chunk.HeadingPath() != expected[idx].path || !strings.HasPrefix(chunk.Text, expected[idx].prefix) {
+			t.Fatal("😡 unexpected chunk:", idx, chunk.HeadingPath(), chunk.Text)
+		}
+		if markdownDocument[chunk.Start:chunk.End] != chunk.Text {
+			t.Fatal("😡 unexpected offsets:", chunk)
+		}
+	}
+	if !strings.Contains(chunks[1].Text, "# this is not a heading") || !strings.HasSuffix(chunks[1].Text, "with a continuation") {
+		t.Fatal("😡 unexpected chunk:", chunks[1].Text)
+	}
+}
+
+func TestSplitMarkdownNeverSplitsFences(t *testing.T) {
+	// the code block is longer than a chunk
+	chunks := SplitMarkdown(markdownDocument, 40, 10)
+	fence := "```bash\n# this is not a heading\ngo get github.com/parakeet-nest/gollama\n\ngo mod tidy\n```"
+
+	found := false
+	for _, chunk := range chunks {
+		if strings.Contains(chunk.Text, "```") {
+			if chunk.Text != fence || chunk.HeadingPath() != "Install > Linux" {
+				t.Fatal("😡 the code block has been split:", chunk.Text)
+			}
+			found = true
+			continue
+		}
+		if len([]rune(chunk.Text)) > 40 {
+			t.Fatal("😡 the chunk is too long:", chunk.Text)
+		}
+		if markdownDocument[chunk.Start:chunk.End] != chunk.Text {
+			t.Fatal("😡 unexpected offsets:", chunk)
+		}
+	}
+	if !found {
+		t.Fatal("😡 the code block is missing:", chunks)
+	}
+
+	// a code block indented in a list item
+	document := "- item\n\n    ```\n    x\n\n    y\n    ```"
+	found = false
+	for _, chunk := range SplitMarkdown(document, 5, 0) {
+		if strings.Contains(chunk.Text, "```") {
+			if chunk.Text != "```\n    x\n\n    y\n    ```" {
+				t.Fatal("😡 the code block of the list has been split:", chunk.Text)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("😡 the code block of the list is missing")
+	}
+
+	// a code block opened after the marker of a list item, closed by an indented fence
+	document = "# Install\n\n- ```sh\n  make\n  ```\n- second item\n\n## Linux\n\nRun apt install.\n\n## Mac\n\nRun brew."
+	chunks = SplitMarkdown(document, 40, 0)
+	if len(chunks) != 3 ||
+		chunks[0].Text != "- ```sh\n  make\n  ```\n- second item" || chunks[0].HeadingPath() != "Install" ||
+		chunks[1].Text != "Run apt install." || chunks[1].HeadingPath() != "Install > Linux" ||
+		chunks[2].Text != "Run brew." || chunks[2].HeadingPath() != "Install > Mac" {
+		t.Fatal("😡 unexpected chunks:", chunks)
+	}
+}
+
+func TestSplitMarkdownUnclosedFence(t *testing.T) {
+	chunks := SplitMarkdown("# Title\n\n~~~~\ncode\n~~~\n# not a heading\n", 1000, 0)
+	if len(chunks) != 1 || chunks[0].HeadingPath() != "Title" || !strings.HasSuffix(chunks[0].Text, "# not a heading") {
+		t.Fatal("😡 unexpected chunks:", chunks)
+	}
+}
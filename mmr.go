@@ -0,0 +1,71 @@
+package gollama
+
+import (
+	"math"
+)
+
+// === Maximal Marginal Relevance ===
+
+// MaxMarginalRelevance reorders the candidates of a similarity search
+// to diversify the results, and returns at most max records.
+// The records are selected one by one, maximizing:
+//
+//	lambda * similarity(question, record) - (1 - lambda) * max(similarity(record, selected record))
+//
+// lambda = 1.0 is the ranking by relevance only, lambda = 0.0 the most diverse results
+// (0.5 is a good trade-off). The similarity with the question is the CosineDistance field
+// of the candidates (set by the searches), the similarity between two records is CosineSimilarity.
+// The Score field of the selected records is their marginal relevance when they were selected.
+func MaxMarginalRelevance(candidates []VectorRecord, max int, lambda float64) []VectorRecord {
+	remaining := make([]VectorRecord, len(candidates))
+	copy(remaining, candidates)
+	// the highest similarity of every remaining candidate with the selected records
+	redundancies := make([]float64, len(remaining))
+	for idx := range redundancies {
+		redundancies[idx] = math.Inf(-1)
+	}
+
+	var selected []VectorRecord
+	for len(selected) < max && len(remaining) > 0 {
+		best := 0
+		bestScore := math.Inf(-1)
+		for idx, record := range remaining {
+			score := lambda * record.CosineDistance
+			if len(selected) > 0 {
+				score -= (1.0 - lambda) * redundancies[idx]
+			}
+			if score > bestScore {
+				best, bestScore = idx, score
+			}
+		}
+
+		record := remaining[best]
+		record.Score = bestScore
+		selected = append(selected, record)
+
+		last := len(remaining) - 1
+		remaining[best], redundancies[best] = remaining[last], redundancies[last]
+		remaining, redundancies = remaining[:last], redundancies[:last]
+		for idx := range remaining {
+			redundancies[idx] = math.Max(redundancies[idx], CosineSimilarity(remaining[idx].Embedding, record.Embedding))
+		}
+	}
+	return selected
+}
+
+// SearchMMR searches the 4 * max most similar records of the store
+// (with a cosine similarity greater than or equal to limit)
+// and returns at most max of them, diversified with MaxMarginalRelevance.
+func SearchMMR(store VectorStore, embeddingFromQuestion VectorRecord, limit float64, max int, lambda float64) ([]VectorRecord, error) {
+	candidates, err := store.SearchTopNSimilarities(embeddingFromQuestion, limit, 4*max)
+	if err != nil {
+		return nil, err
+	}
+	return MaxMarginalRelevance(candidates, max, lambda), nil
+}
+
+// SearchMMRSimilarities is like SearchTopNSimilarities,
+// but the results are diversified with MaxMarginalRelevance (see SearchMMR).
+func (mvs *MemoryVectorStore) SearchMMRSimilarities(embeddingFromQuestion VectorRecord, limit float64, max int, lambda float64) ([]VectorRecord, error) {
+	return SearchMMR(mvs, embeddingFromQuestion, limit, max, lambda)
+}
@@ -0,0 +1,40 @@
+package gollama
+
+import (
+	"testing"
+)
+
+func TestSearchMMR(t *testing.T) {
+	store := NewMemoryVectorStore()
+	// two near-duplicates of the same paragraph, and a different one
+	store.Save(VectorRecord{Id: "warp-1", Embedding: []float64{1.0, 0.1, 0.0}})
+	store.Save(VectorRecord{Id: "warp-2", Embedding: []float64{1.0, 0.11, 0.0}})
+	store.Save(VectorRecord{Id: "transporter", Embedding: []float64{0.7, 0.0, 0.7}})
+	store.Save(VectorRecord{Id: "holodeck", Embedding: []float64{0.0, 0.0, 1.0}})
+	question := VectorRecord{Embedding: []float64{1.0, 0.1, 0.1}}
+
+	similarities, err := store.SearchTopNSimilarities(question, 0.5, 2)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if similarities[0].Id != "warp-1" || similarities[1].Id != "warp-2" {
+		t.Fatal("😡 unexpected similarities:", similarities)
+	}
+
+	similarities, err = store.SearchMMRSimilarities(question, 0.5, 2, 0.5)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(similarities) != 2 || similarities[0].Id != "warp-1" || similarities[1].Id != "transporter" {
+		t.Fatal("😡 unexpected similarities:", similarities)
+	}
+	if similarities[0].CosineDistance == 0.0 || similarities[1].Score >= similarities[0].Score {
+		t.Fatal("😡 unexpected scores:", similarities)
+	}
+
+	// lambda = 1.0: relevance only
+	similarities, _ = SearchMMR(store, question, 0.5, 2, 1.0)
+	if similarities[0].Id != "warp-1" || similarities[1].Id != "warp-2" {
+		t.Fatal("😡 unexpected similarities:", similarities)
+	}
+}
@@ -0,0 +1,191 @@
+package gollama
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// === Models management ===
+// https://github.com/ollama/ollama/blob/main/docs/api.md
+
+// ModelDetails describes the format and the size of a model.
+type ModelDetails struct {
+	ParentModel       string   `json:"parent_model"`
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
+// ModelInfo is a local model, as returned by /api/tags.
+type ModelInfo struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    ModelDetails `json:"details"`
+}
+
+// RunningModel is a model loaded into memory, as returned by /api/ps.
+type RunningModel struct {
+	Name      string       `json:"name"`
+	Model     string       `json:"model"`
+	Size      int64        `json:"size"`
+	Digest    string       `json:"digest"`
+	Details   ModelDetails `json:"details"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	SizeVRAM  int64        `json:"size_vram"`
+}
+
+// ShowModelResponse is the information about a model returned by /api/show.
+type ShowModelResponse struct {
+	License    string                 `json:"license"`
+	Modelfile  string                 `json:"modelfile"`
+	Parameters string                 `json:"parameters"`
+	Template   string                 `json:"template"`
+	System     string                 `json:"system"`
+	Details    ModelDetails           `json:"details"`
+	ModelInfo  map[string]interface{} `json:"model_info"`
+	ModifiedAt time.Time              `json:"modified_at"`
+}
+
+// PullQuery is the request of the /api/pull endpoint.
+type PullQuery struct {
+	Model    string `json:"model"`
+	Insecure bool   `json:"insecure,omitempty"` // allow insecure connections to the registry
+	Stream   bool   `json:"stream"`
+}
+
+// Progress is a progress event streamed by /api/pull, /api/push and /api/create.
+// Total and Completed are in bytes and are only set while downloading (or uploading) a layer.
+type Progress struct {
+	Status    string `json:"status"` // ex: "pulling manifest", "verifying sha256 digest", "success"
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// Percent returns the completion of the current layer (between 0 and 100).
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0.0
+	}
+	return float64(p.Completed) * 100.0 / float64(p.Total)
+}
+
+type modelQuery struct {
+	Model string `json:"model"`
+}
+
+type copyQuery struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// ListModels returns the models available locally.
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var response struct {
+		Models []ModelInfo `json:"models"`
+	}
+	err := c.sendJSON(ctx, http.MethodGet, "/api/tags", nil, "", "", &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Models, nil
+}
+
+// ListRunningModels returns the models currently loaded into memory.
+func (c *Client) ListRunningModels(ctx context.Context) ([]RunningModel, error) {
+	var response struct {
+		Models []RunningModel `json:"models"`
+	}
+	err := c.sendJSON(ctx, http.MethodGet, "/api/ps", nil, "", "", &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Models, nil
+}
+
+// ShowModel returns the information about a model (Modelfile, template, parameters...).
+func (c *Client) ShowModel(ctx context.Context, model string) (ShowModelResponse, error) {
+	var response ShowModelResponse
+	err := c.sendJSON(ctx, http.MethodPost, "/api/show", modelQuery{Model: model}, "", "", &response)
+	if err != nil {
+		return ShowModelResponse{}, err
+	}
+	return response, nil
+}
+
+// PullModel downloads a model from the registry
+// and calls onProgress (if not nil) for every progress event.
+// If onProgress returns an error, the download is stopped and the error is returned.
+func (c *Client) PullModel(ctx context.Context, query PullQuery, onProgress func(Progress) error) error {
+	query.Stream = true
+	return c.streamProgress(ctx, "/api/pull", query, onProgress)
+}
+
+// DeleteModel deletes a model and its data.
+func (c *Client) DeleteModel(ctx context.Context, model string) error {
+	return c.sendJSON(ctx, http.MethodDelete, "/api/delete", modelQuery{Model: model}, "", "", nil)
+}
+
+// CopyModel creates a model with the destination name from the source model.
+func (c *Client) CopyModel(ctx context.Context, source, destination string) error {
+	return c.sendJSON(ctx, http.MethodPost, "/api/copy", copyQuery{Source: source, Destination: destination}, "", "", nil)
+}
+
+// HasModel reports whether the model is available locally.
+func (c *Client) HasModel(ctx context.Context, model string) (bool, error) {
+	_, err := c.ShowModel(ctx, model)
+	if err != nil {
+		if IsModelNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// EnsureModel pulls the model if it is not available locally
+// (ex: to load the LLMs before running the tests).
+func (c *Client) EnsureModel(ctx context.Context, model string, onProgress func(Progress) error) error {
+	found, err := c.HasModel(ctx, model)
+	if err != nil {
+		return err
+	}
+	if found {
+		return nil
+	}
+	return c.PullModel(ctx, PullQuery{Model: model}, onProgress)
+}
+
+func ListModels(ctx context.Context, url string) ([]ModelInfo, error) {
+	return NewClient(url).ListModels(ctx)
+}
+
+func ListRunningModels(ctx context.Context, url string) ([]RunningModel, error) {
+	return NewClient(url).ListRunningModels(ctx)
+}
+
+func ShowModel(ctx context.Context, url string, model string) (ShowModelResponse, error) {
+	return NewClient(url).ShowModel(ctx, model)
+}
+
+func PullModel(ctx context.Context, url string, query PullQuery, onProgress func(Progress) error) error {
+	return NewClient(url).PullModel(ctx, query, onProgress)
+}
+
+func DeleteModel(ctx context.Context, url string, model string) error {
+	return NewClient(url).DeleteModel(ctx, model)
+}
+
+func CopyModel(ctx context.Context, url string, source, destination string) error {
+	return NewClient(url).CopyModel(ctx, source, destination)
+}
+
+func EnsureModel(ctx context.Context, url string, model string, onProgress func(Progress) error) error {
+	return NewClient(url).EnsureModel(ctx, model, onProgress)
+}
@@ -0,0 +1,116 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newModelsServer(t *testing.T, models map[string]bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var query map[string]interface{}
+		if r.Body != nil {
+			json.NewDecoder(r.Body).Decode(&query)
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/tags":
+			w.Write([]byte(`{"models":[{"name":"tinyllama:latest","model":"tinyllama:latest","size":637700138,"details":{"family":"llama","parameter_size":"1B"}}]}`))
+		case "GET /api/ps":
+			w.Write([]byte(`{"models":[{"name":"tinyllama:latest","size_vram":637700138}]}`))
+		case "POST /api/show":
+			if !models[query["model"].(string)] {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":"model '` + query["model"].(string) + `' not found"}`))
+				return
+			}
+			w.Write([]byte(`{"modelfile":"FROM tinyllama","details":{"family":"llama"}}`))
+		case "POST /api/pull":
+			w.Write([]byte(`{"status":"pulling manifest"}` + "\n"))
+			w.Write([]byte(`{"status":"downloading","digest":"sha256:2af3b81862c6","total":100,"completed":50}` + "\n"))
+			w.Write([]byte(`{"status":"success"}` + "\n"))
+			models[query["model"].(string)] = true
+		case "DELETE /api/delete":
+			delete(models, query["model"].(string))
+		case "POST /api/copy":
+			models[query["destination"].(string)] = true
+		default:
+			t.Errorf("😡 unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestModelsManagement(t *testing.T) {
+	models := map[string]bool{"tinyllama": true}
+	server := newModelsServer(t, models)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := NewClient(server.URL)
+
+	list, err := client.ListModels(ctx)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(list) != 1 || list[0].Name != "tinyllama:latest" || list[0].Details.Family != "llama" {
+		t.Fatal("😡 unexpected models:", list)
+	}
+
+	running, err := client.ListRunningModels(ctx)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(running) != 1 || running[0].SizeVRAM != 637700138 {
+		t.Fatal("😡 unexpected running models:", running)
+	}
+
+	info, err := client.ShowModel(ctx, "tinyllama")
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if info.Modelfile != "FROM tinyllama" {
+		t.Fatal("😡 unexpected model info:", info)
+	}
+
+	if err := client.CopyModel(ctx, "tinyllama", "my-tinyllama"); err != nil {
+		t.Fatal("😡:", err)
+	}
+	if err := client.DeleteModel(ctx, "my-tinyllama"); err != nil {
+		t.Fatal("😡:", err)
+	}
+	if found, _ := client.HasModel(ctx, "my-tinyllama"); found {
+		t.Fatal("😡 the model should be deleted")
+	}
+}
+
+func TestEnsureModel(t *testing.T) {
+	models := map[string]bool{}
+	server := newModelsServer(t, models)
+	defer server.Close()
+
+	var events []Progress
+	err := EnsureModel(context.Background(), server.URL, "all-minilm", func(progress Progress) error {
+		events = append(events, progress)
+		return nil
+	})
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(events) != 3 || events[1].Percent() != 50.0 || events[2].Status != "success" {
+		t.Fatal("😡 unexpected progress events:", events)
+	}
+	if !models["all-minilm"] {
+		t.Fatal("😡 the model should be pulled")
+	}
+
+	// the model is present: no pull
+	events = nil
+	if err := EnsureModel(context.Background(), server.URL, "all-minilm", func(progress Progress) error {
+		events = append(events, progress)
+		return nil
+	}); err != nil || len(events) != 0 {
+		t.Fatal("😡 the model should not be pulled again:", err, events)
+	}
+}
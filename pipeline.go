@@ -0,0 +1,177 @@
+package gollama
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// === Embedding pipeline ===
+
+// VectorRecordSaver is the part of a vector store used by EmbedAll
+// to save the vector records (MemoryVectorStore implements it).
+type VectorRecordSaver interface {
+	Save(vectorRecord VectorRecord) (VectorRecord, error)
+}
+
+// EmbedAllOptions configures EmbedAll.
+type EmbedAllOptions struct {
+	Model string // the embedding model (ex: "all-minilm")
+
+	Workers    int           // number of concurrent requests to Ollama (4 by default)
+	MaxRetries int           // number of retries of a transient failure (3 by default, -1 to disable)
+	RetryDelay time.Duration // delay before the first retry, doubled at every retry (500ms by default)
+
+	// IdFunc computes the id of a VectorRecord from the index and the text of the input
+	// (the index is used by default).
+	IdFunc func(index int, text string) string
+
+	// OnProgress is called every time a text has been embedded
+	// (the calls are serialized, it is safe to print or update a counter).
+	OnProgress func(done, total int)
+
+	// Store, if not nil, receives every vector record as soon as it is created
+	// (the calls to Save are serialized).
+	Store VectorRecordSaver
+}
+
+// EmbedAll creates the embeddings of all the texts with several concurrent workers.
+// Transient failures (network errors, 429 and 5xx status codes) are retried.
+// The first other error stops the pipeline and is returned.
+//
+// Parameters:
+//   - ctx: the pipeline is stopped when ctx is done.
+//   - texts: the texts to embed.
+//   - options: the model, the number of workers, the retries, the progress callback and the store.
+//
+// Returns:
+//   - []VectorRecord: one vector record per text, in the same order as the texts.
+//   - error: an error if any occurred.
+func (c *Client) EmbedAll(ctx context.Context, texts []string, options EmbedAllOptions) ([]VectorRecord, error) {
+	if options.Workers <= 0 {
+		options.Workers = 4
+	}
+	if options.MaxRetries == 0 {
+		options.MaxRetries = 3
+	}
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = 500 * time.Millisecond
+	}
+	if options.IdFunc == nil {
+		options.IdFunc = func(index int, text string) string {
+			return strconv.Itoa(index)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	records := make([]VectorRecord, len(texts))
+	jobs := make(chan int)
+
+	var mutex sync.Mutex
+	var firstErr error
+	done := 0
+
+	fail := func(err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < options.Workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				record, err := c.embedWithRetries(ctx, options, index, texts[index])
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				mutex.Lock()
+				if options.Store != nil && firstErr == nil {
+					if _, err := options.Store.Save(record); err != nil {
+						firstErr = err
+						cancel()
+					}
+				}
+				records[index] = record
+				done++
+				if options.OnProgress != nil && firstErr == nil {
+					options.OnProgress(done, len(texts))
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for index := range texts {
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return records, nil
+}
+
+func (c *Client) embedWithRetries(ctx context.Context, options EmbedAllOptions, index int, text string) (VectorRecord, error) {
+	delay := options.RetryDelay
+	for attempt := 0; ; attempt++ {
+		records, err := c.CreateEmbeddings(ctx, options.Model, []string{text})
+		if err == nil {
+			record := records[0]
+			record.Id = options.IdFunc(index, text)
+			return record, nil
+		}
+		if attempt >= options.MaxRetries || !isTransient(err) {
+			return VectorRecord{}, err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return VectorRecord{}, ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// isTransient reports whether a request can be retried:
+// network errors, 429 Too Many Requests and 5xx status codes.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return apiError.StatusCode == http.StatusTooManyRequests || apiError.StatusCode >= 500
+	}
+	var netError net.Error
+	return errors.As(err, &netError)
+}
+
+func EmbedAll(ctx context.Context, url string, texts []string, options EmbedAllOptions) ([]VectorRecord, error) {
+	return NewClient(url).EmbedAll(ctx, texts, options)
+}
@@ -0,0 +1,84 @@
+package gollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEmbedAll(t *testing.T) {
+	var mutex sync.Mutex
+	failures := map[string]int{"doc 3": 2} // the embedding of "doc 3" fails twice
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var query Query4Embeddings
+		json.NewDecoder(r.Body).Decode(&query)
+
+		mutex.Lock()
+		if failures[query.Input[0]] > 0 {
+			failures[query.Input[0]]--
+			mutex.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mutex.Unlock()
+
+		json.NewEncoder(w).Encode(EmbeddingsResponse{Embeddings: [][]float64{{float64(len(query.Input[0])), 1.0}}})
+	}))
+	defer server.Close()
+
+	var texts []string
+	for idx := 0; idx < 20; idx++ {
+		texts = append(texts, "doc "+strconv.Itoa(idx))
+	}
+
+	store := MemoryVectorStore{
+		Records: make(map[string]VectorRecord),
+	}
+	progress := 0
+
+	records, err := EmbedAll(context.Background(), server.URL, texts, EmbedAllOptions{
+		Model:      "all-minilm",
+		Workers:    5,
+		RetryDelay: time.Millisecond,
+		IdFunc: func(index int, text string) string {
+			return "id-" + strconv.Itoa(index)
+		},
+		OnProgress: func(done, total int) {
+			progress = done
+		},
+		Store: &store,
+	})
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if progress != len(texts) || len(store.Records) != len(texts) {
+		t.Fatal("😡 unexpected progress or store size:", progress, len(store.Records))
+	}
+	for idx, record := range records {
+		if record.Id != "id-"+strconv.Itoa(idx) || record.Prompt != texts[idx] {
+			t.Fatal("😡 the order is not preserved:", idx, record)
+		}
+	}
+}
+
+func TestEmbedAllError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"all-minilm\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	_, err := EmbedAll(context.Background(), server.URL, []string{"a", "b", "c"}, EmbedAllOptions{
+		Model:      "all-minilm",
+		RetryDelay: time.Millisecond,
+	})
+	if !IsModelNotFound(err) {
+		t.Fatal("😡 expected a model not found error, got:", err)
+	}
+}
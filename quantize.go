@@ -0,0 +1,387 @@
+package gollama
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// === Quantized Vector Store ===
+
+// Precision is the storage format of the embeddings in a QuantizedVectorStore.
+type Precision int
+
+const (
+	Float64Precision Precision = iota // 8 bytes per dimension (no loss)
+	Float32Precision                  // 4 bytes per dimension
+	Int8Precision                     // 1 byte per dimension + a float32 scale per vector (scalar quantization)
+)
+
+func (precision Precision) String() string {
+	switch precision {
+	case Float64Precision:
+		return "float64"
+	case Float32Precision:
+		return "float32"
+	case Int8Precision:
+		return "int8"
+	}
+	return "unknown"
+}
+
+// QuantizeInt8 quantizes the vector with a symmetric scalar quantization:
+// every value is rounded to value / scale, with scale = max(|value|) / 127.
+// The value is approximately int8 * scale (see DequantizeInt8).
+func QuantizeInt8(vector []float64) ([]int8, float32) {
+	maxAbs := 0.0
+	for _, value := range vector {
+		maxAbs = math.Max(maxAbs, math.Abs(value))
+	}
+	quantized := make([]int8, len(vector))
+	if maxAbs == 0.0 {
+		return quantized, 0.0
+	}
+	scale := maxAbs / 127.0
+	for i, value := range vector {
+		quantized[i] = int8(math.Round(value / scale))
+	}
+	return quantized, float32(scale)
+}
+
+// DequantizeInt8 returns the approximate vector of a vector quantized with QuantizeInt8.
+func DequantizeInt8(quantized []int8, scale float32) []float64 {
+	vector := make([]float64, len(quantized))
+	for i, value := range quantized {
+		vector[i] = float64(value) * float64(scale)
+	}
+	return vector
+}
+
+// compactVector is an embedding stored with the precision of the store.
+type compactVector struct {
+	float64s []float64
+	float32s []float32
+	int8s    []int8
+	scale    float32 // of the int8 values
+	norm     float64 // of the stored values (float64s, float32s or int8s)
+}
+
+// QuantizedVectorStore is an in-memory vector store keeping the embeddings
+// in a compact form (float32 or int8) to cut the memory use.
+// The cosine similarities are computed on the compact form:
+// the question is converted (or quantized) to the precision of the store.
+// Get, GetAll and the searches return the records with the embeddings
+// converted back to float64 (an approximation of the saved embeddings).
+// The embedding model and dimension are recorded on the first insert (see MemoryVectorStore).
+//
+// A QuantizedVectorStore is safe for concurrent use.
+type QuantizedVectorStore struct {
+	precision Precision
+	records   map[string]VectorRecord // the records without their embeddings
+	vectors   map[string]compactVector
+	spec      embeddingSpec
+	mutex     sync.RWMutex
+}
+
+var _ FilterableVectorStore = (*QuantizedVectorStore)(nil)
+var _ MetricVectorStore = (*QuantizedVectorStore)(nil)
+
+// NewQuantizedVectorStore creates an empty QuantizedVectorStore storing the embeddings with the precision.
+func NewQuantizedVectorStore(precision Precision) *QuantizedVectorStore {
+	return &QuantizedVectorStore{
+		precision: precision,
+		records:   make(map[string]VectorRecord),
+		vectors:   make(map[string]compactVector),
+	}
+}
+
+// Precision returns the storage format of the embeddings.
+func (store *QuantizedVectorStore) Precision() Precision {
+	return store.precision
+}
+
+// EmbeddingsSize returns the number of bytes used to store the embeddings.
+func (store *QuantizedVectorStore) EmbeddingsSize() int {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	size := 0
+	for _, vector := range store.vectors {
+		size += 8*len(vector.float64s) + 4*len(vector.float32s) + len(vector.int8s)
+		if vector.int8s != nil {
+			size += 4 // scale
+		}
+	}
+	return size
+}
+
+func (store *QuantizedVectorStore) compact(embedding []float64) compactVector {
+	var vector compactVector
+	switch store.precision {
+	case Float32Precision:
+		vector.float32s = make([]float32, len(embedding))
+		sum := 0.0
+		for i, value := range embedding {
+			vector.float32s[i] = float32(value)
+			sum += float64(vector.float32s[i]) * float64(vector.float32s[i])
+		}
+		vector.norm = math.Sqrt(sum)
+	case Int8Precision:
+		vector.int8s, vector.scale = QuantizeInt8(embedding)
+		sum := 0
+		for _, value := range vector.int8s {
+			sum += int(value) * int(value)
+		}
+		vector.norm = math.Sqrt(float64(sum))
+	default:
+		vector.float64s = append([]float64(nil), embedding...)
+		vector.norm = math.Sqrt(dotProduct(embedding, embedding))
+	}
+	return vector
+}
+
+func (vector compactVector) embedding() []float64 {
+	switch {
+	case vector.float32s != nil:
+		embedding := make([]float64, len(vector.float32s))
+		for i, value := range vector.float32s {
+			embedding[i] = float64(value)
+		}
+		return embedding
+	case vector.int8s != nil:
+		return DequantizeInt8(vector.int8s, vector.scale)
+	}
+	return append([]float64(nil), vector.float64s...)
+}
+
+// similarity returns the cosine similarity of two vectors of the same precision.
+func (vector compactVector) similarity(other compactVector) float64 {
+	if vector.norm == 0.0 || other.norm == 0.0 {
+		return 0.0
+	}
+	switch {
+	case vector.float32s != nil:
+		var sum float32
+		for i := range vector.float32s {
+			sum += vector.float32s[i] * other.float32s[i]
+		}
+		return float64(sum) / (vector.norm * other.norm)
+	case vector.int8s != nil:
+		// the scales cancel out: cosine(a*x, b*y) = cosine(x, y)
+		var sum int32
+		for i := range vector.int8s {
+			sum += int32(vector.int8s[i]) * int32(other.int8s[i])
+		}
+		return float64(sum) / (vector.norm * other.norm)
+	}
+	return dotProduct(vector.float64s, other.float64s) / (vector.norm * other.norm)
+}
+
+func (store *QuantizedVectorStore) record(id string) VectorRecord {
+	record := store.records[id]
+	record.Embedding = store.vectors[id].embedding()
+	return record
+}
+
+func (store *QuantizedVectorStore) Get(id string) (VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	if _, ok := store.records[id]; !ok {
+		return VectorRecord{}, recordNotFound(id)
+	}
+	return store.record(id), nil
+}
+
+func (store *QuantizedVectorStore) GetAll() ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	var records []VectorRecord
+	for id := range store.records {
+		records = append(records, store.record(id))
+	}
+	return records, nil
+}
+
+func (store *QuantizedVectorStore) Save(vectorRecord VectorRecord) (VectorRecord, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if err := store.spec.check(vectorRecord); err != nil {
+		return VectorRecord{}, err
+	}
+	store.spec.update(vectorRecord)
+	previous, exists := store.records[vectorRecord.Id]
+	vectorRecord = nextVersion(vectorRecord, previous, exists)
+	vector := store.compact(vectorRecord.Embedding)
+	record := vectorRecord
+	record.Embedding = nil
+	store.records[record.Id] = record
+	store.vectors[record.Id] = vector
+	return vectorRecord, nil
+}
+
+func (store *QuantizedVectorStore) Delete(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.records, id)
+	delete(store.vectors, id)
+	return nil
+}
+
+// DeleteWhere deletes the records matching the filter and returns the number of deleted records.
+func (store *QuantizedVectorStore) DeleteWhere(filter Filter) (int, error) {
+	if filter == nil {
+		return 0, ErrNilFilter
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	deleted := 0
+	for id, record := range store.records {
+		if filter(record) {
+			delete(store.records, id)
+			delete(store.vectors, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (store *QuantizedVectorStore) Count() (int, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return len(store.records), nil
+}
+
+// SearchSimilarities returns all the records with a cosine similarity
+// (computed on the compact form) greater than or equal to limit.
+func (store *QuantizedVectorStore) SearchSimilarities(embeddingFromQuestion VectorRecord, limit float64) ([]VectorRecord, error) {
+	return store.SearchSimilaritiesWithFilter(embeddingFromQuestion, limit, nil)
+}
+
+// SearchSimilaritiesWithFilter is like SearchSimilarities,
+// but only the records matching the filter are compared (a nil filter matches all the records).
+func (store *QuantizedVectorStore) SearchSimilaritiesWithFilter(embeddingFromQuestion VectorRecord, limit float64, filter Filter) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	if err := store.spec.check(embeddingFromQuestion); err != nil {
+		return nil, err
+	}
+	return store.scoredRecords(store.similarities(embeddingFromQuestion, limit, filter), CosineMetric), nil
+}
+
+// SearchTopNSimilarities returns the max most similar records
+// with a cosine similarity greater than or equal to limit, the most similar first.
+func (store *QuantizedVectorStore) SearchTopNSimilarities(embeddingFromQuestion VectorRecord, limit float64, max int) ([]VectorRecord, error) {
+	return store.SearchTopNSimilaritiesWithFilter(embeddingFromQuestion, limit, max, nil)
+}
+
+// SearchTopNSimilaritiesWithFilter is like SearchTopNSimilarities,
+// but only the records matching the filter are compared (a nil filter matches all the records).
+// Only the max most similar embeddings are converted back to float64.
+func (store *QuantizedVectorStore) SearchTopNSimilaritiesWithFilter(embeddingFromQuestion VectorRecord, limit float64, max int, filter Filter) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	if err := store.spec.check(embeddingFromQuestion); err != nil {
+		return nil, err
+	}
+	similarities := store.similarities(embeddingFromQuestion, limit, filter)
+	sort.Slice(similarities, func(i, j int) bool {
+		return similarities[i].Score > similarities[j].Score
+	})
+	if max < 0 {
+		max = 0
+	}
+	if len(similarities) > max {
+		similarities = similarities[:max]
+	}
+	return store.scoredRecords(similarities, CosineMetric), nil
+}
+
+// similarities returns the ids of the records matching the filter with a cosine similarity
+// (computed on the compact form) greater than or equal to limit.
+// The caller must hold the lock of the store.
+func (store *QuantizedVectorStore) similarities(embeddingFromQuestion VectorRecord, limit float64, filter Filter) []ScoredId {
+	question := store.compact(embeddingFromQuestion.Embedding)
+	var similarities []ScoredId
+	for id, vector := range store.vectors {
+		if filter != nil && !filter(store.records[id]) {
+			continue
+		}
+		if similarity := question.similarity(vector); similarity >= limit {
+			similarities = append(similarities, ScoredId{Id: id, Score: similarity})
+		}
+	}
+	return similarities
+}
+
+// scoredRecords returns the records of the ids, with their embeddings converted back to float64
+// and their score in Score (and in CosineDistance for the cosine similarity).
+// The caller must hold the lock of the store.
+func (store *QuantizedVectorStore) scoredRecords(scores []ScoredId, metric Metric) []VectorRecord {
+	var records []VectorRecord
+	for _, score := range scores {
+		record := store.record(score.Id)
+		record.Score = score.Score
+		if metric == CosineMetric {
+			record.CosineDistance = score.Score
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// SearchSimilaritiesWithMetric is like MemoryVectorStore.SearchSimilaritiesWithMetric.
+// The cosine similarity is computed on the compact form, the other metrics
+// on the embeddings converted back to float64 (one at a time).
+func (store *QuantizedVectorStore) SearchSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, metric Metric) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	scores, err := store.metricScores(embeddingFromQuestion, limit, metric)
+	if err != nil {
+		return nil, err
+	}
+	return store.scoredRecords(scores, metric), nil
+}
+
+// SearchTopNSimilaritiesWithMetric is like SearchSimilaritiesWithMetric,
+// but returns at most max records, the most similar first.
+// Only the max most similar embeddings are converted back to float64.
+func (store *QuantizedVectorStore) SearchTopNSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, max int, metric Metric) ([]VectorRecord, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	scores, err := store.metricScores(embeddingFromQuestion, limit, metric)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		return metric.Better(scores[i].Score, scores[j].Score)
+	})
+	if max < 0 {
+		max = 0
+	}
+	if len(scores) > max {
+		scores = scores[:max]
+	}
+	return store.scoredRecords(scores, metric), nil
+}
+
+// metricScores returns the ids of the records passing the limit with their score.
+// The caller must hold the lock of the store.
+func (store *QuantizedVectorStore) metricScores(embeddingFromQuestion VectorRecord, limit float64, metric Metric) ([]ScoredId, error) {
+	if metric.comparesEmbeddings() {
+		if err := store.spec.check(embeddingFromQuestion); err != nil {
+			return nil, err
+		}
+	}
+	if metric == CosineMetric {
+		return store.similarities(embeddingFromQuestion, limit, nil), nil
+	}
+	var scores []ScoredId
+	for id, record := range store.records {
+		if metric.comparesEmbeddings() {
+			record.Embedding = store.vectors[id].embedding()
+		}
+		if score := metric.Score(embeddingFromQuestion, record); metric.Passes(score, limit) {
+			scores = append(scores, ScoredId{Id: id, Score: score})
+		}
+	}
+	return scores, nil
+}
@@ -0,0 +1,97 @@
+package gollama
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestQuantizeInt8(t *testing.T) {
+	quantized, scale := QuantizeInt8([]float64{0.5, -1.0, 0.25, 0.0})
+	if quantized[0] != 64 || quantized[1] != -127 || quantized[2] != 32 || quantized[3] != 0 {
+		t.Fatal("😡 unexpected quantized vector:", quantized)
+	}
+	for i, value := range DequantizeInt8(quantized, scale) {
+		if math.Abs(value-[]float64{0.5, -1.0, 0.25, 0.0}[i]) > 0.005 {
+			t.Fatal("😡 unexpected dequantized value:", i, value)
+		}
+	}
+	if quantized, scale := QuantizeInt8([]float64{0.0, 0.0}); scale != 0.0 || quantized[0] != 0 {
+		t.Fatal("😡 unexpected quantization of a zero vector:", quantized, scale)
+	}
+}
+
+// TestQuantizedAccuracy compares the searches on the compact forms with the full precision searches.
+func TestQuantizedAccuracy(t *testing.T) {
+	random := rand.New(rand.NewSource(3))
+	records := randomRecords(random, 2000, 64)
+	questions := randomRecords(random, 50, 64)
+
+	fullPrecision := NewMemoryVectorStore()
+	for _, record := range records {
+		fullPrecision.Save(record)
+	}
+
+	tests := []struct {
+		precision Precision
+		maxError  float64 // of the cosine similarities
+		minRecall float64 // of the top 10
+		bytesPer  int     // per embedding
+	}{
+		{Float32Precision, 1e-5, 1.0, 4 * 64},
+		{Int8Precision, 0.01, 0.9, 64 + 4},
+	}
+
+	for _, test := range tests {
+		store := NewQuantizedVectorStore(test.precision)
+		for _, record := range records {
+			store.Save(record)
+		}
+		if size := store.EmbeddingsSize(); size != test.bytesPer*len(records) {
+			t.Fatal("😡", test.precision, "unexpected size:", size)
+		}
+
+		totalRecall := 0.0
+		for _, question := range questions {
+			exact, _ := fullPrecision.SearchTopNSimilarities(question, -1.0, 10)
+			approximate, err := store.SearchTopNSimilarities(question, -1.0, 10)
+			if err != nil {
+				t.Fatal("😡:", err)
+			}
+			totalRecall += recall(exact, approximate)
+
+			for _, record := range approximate {
+				expected := CosineSimilarity(question.Embedding, fullPrecision.Records[record.Id].Embedding)
+				if math.Abs(record.CosineDistance-expected) > test.maxError {
+					t.Fatal("😡", test.precision, "cosine similarity error:", record.CosineDistance, expected)
+				}
+			}
+		}
+		averageRecall := totalRecall / float64(len(questions))
+		t.Log("🙂", test.precision, "recall:", averageRecall)
+		if averageRecall < test.minRecall {
+			t.Fatal("😡", test.precision, "recall too low:", averageRecall)
+		}
+	}
+}
+
+func TestQuantizedTopNConvertsOnlyTheTopN(t *testing.T) {
+	random := rand.New(rand.NewSource(4))
+	store := NewQuantizedVectorStore(Int8Precision)
+	for _, record := range randomRecords(random, 1000, 64) {
+		store.Save(record)
+	}
+	question := randomRecords(random, 1, 64)[0]
+
+	// the embeddings of the records below the top 3 are not converted back to float64
+	allocations := testing.AllocsPerRun(10, func() {
+		store.SearchTopNSimilarities(question, -1.0, 3)
+	})
+	if allocations > 100 {
+		t.Fatal("😡 too many allocations for a top 3:", allocations)
+	}
+	records, _ := store.SearchTopNSimilarities(question, -1.0, 3)
+	if len(records) != 3 || records[0].Score < records[1].Score || records[1].Score < records[2].Score || len(records[0].Embedding) != 64 {
+		t.Fatal("😡 unexpected records:", records)
+	}
+}
@@ -0,0 +1,220 @@
+package gollama
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// === Similarity and distance metrics ===
+
+// Metric is the way to compare a vector record with the question during a search.
+// The similarity metrics (Cosine, DotProduct, Jaccard, Levenshtein) return a score
+// where the higher is the more similar; the distance metrics (Euclidean, Manhattan)
+// return a score where the lower is the more similar (see IsDistance).
+//
+// Cosine, DotProduct, Euclidean and Manhattan compare the embeddings,
+// Jaccard and Levenshtein compare the texts (Text, or Prompt if Text is empty).
+type Metric int
+
+const (
+	CosineMetric      Metric = iota // cosine similarity of the embeddings, between -1 and 1
+	DotProductMetric                // dot product of the embeddings
+	EuclideanMetric                 // Euclidean (L2) distance between the embeddings
+	ManhattanMetric                 // Manhattan (L1) distance between the embeddings
+	JaccardMetric                   // Jaccard similarity of the sets of words of the texts, between 0 and 1
+	LevenshteinMetric               // Levenshtein similarity of the texts, between 0 and 1
+)
+
+func (metric Metric) String() string {
+	switch metric {
+	case CosineMetric:
+		return "cosine"
+	case DotProductMetric:
+		return "dot product"
+	case EuclideanMetric:
+		return "euclidean"
+	case ManhattanMetric:
+		return "manhattan"
+	case JaccardMetric:
+		return "jaccard"
+	case LevenshteinMetric:
+		return "levenshtein"
+	}
+	return "unknown"
+}
+
+// IsDistance reports whether the lower score is the more similar.
+func (metric Metric) IsDistance() bool {
+	return metric == EuclideanMetric || metric == ManhattanMetric
+}
+
+// comparesEmbeddings reports whether the metric compares the embeddings (and not the texts).
+func (metric Metric) comparesEmbeddings() bool {
+	return metric != JaccardMetric && metric != LevenshteinMetric
+}
+
+// Score compares the record with the question.
+// For a metric comparing the embeddings, a record whose embedding has not the length
+// of the question gets the worst score (-Inf for a similarity, +Inf for a distance),
+// so it never passes the limit of a search.
+func (metric Metric) Score(question, record VectorRecord) float64 {
+	if metric.comparesEmbeddings() && len(question.Embedding) != len(record.Embedding) {
+		if metric.IsDistance() {
+			return math.Inf(1)
+		}
+		return math.Inf(-1)
+	}
+	switch metric {
+	case DotProductMetric:
+		return DotProduct(question.Embedding, record.Embedding)
+	case EuclideanMetric:
+		return EuclideanDistance(question.Embedding, record.Embedding)
+	case ManhattanMetric:
+		return ManhattanDistance(question.Embedding, record.Embedding)
+	case JaccardMetric:
+		return JaccardSimilarity(recordText(question), recordText(record))
+	case LevenshteinMetric:
+		return LevenshteinSimilarity(recordText(question), recordText(record))
+	}
+	return CosineSimilarity(question.Embedding, record.Embedding)
+}
+
+// Passes reports whether the score satisfies the limit of a search:
+// greater than or equal to the limit for a similarity, less than or equal for a distance.
+func (metric Metric) Passes(score, limit float64) bool {
+	if metric.IsDistance() {
+		return score <= limit
+	}
+	return score >= limit
+}
+
+// Better reports whether score1 is more similar than score2.
+func (metric Metric) Better(score1, score2 float64) bool {
+	if metric.IsDistance() {
+		return score1 < score2
+	}
+	return score1 > score2
+}
+
+func recordText(record VectorRecord) string {
+	if record.Text != "" {
+		return record.Text
+	}
+	return record.Prompt
+}
+
+// DotProduct returns the dot product of two vectors
+// (equal to the cosine similarity for normalized vectors),
+// or 0 if the vectors have different lengths.
+func DotProduct(v1, v2 []float64) float64 {
+	if len(v1) != len(v2) {
+		return 0.0
+	}
+	return dotProduct(v1, v2)
+}
+
+// EuclideanDistance returns the Euclidean (L2) distance between two vectors:
+// 0 for identical vectors, the lower, the more similar.
+// The distance between vectors of different lengths is +Inf.
+func EuclideanDistance(v1, v2 []float64) float64 {
+	if len(v1) != len(v2) {
+		return math.Inf(1)
+	}
+	sum := 0.0
+	for i := range v1 {
+		diff := v1[i] - v2[i]
+		sum += diff * diff
+	}
+	return math.Sqrt(sum)
+}
+
+// ManhattanDistance returns the Manhattan (L1) distance between two vectors:
+// 0 for identical vectors, the lower, the more similar.
+// The distance between vectors of different lengths is +Inf.
+func ManhattanDistance(v1, v2 []float64) float64 {
+	if len(v1) != len(v2) {
+		return math.Inf(1)
+	}
+	sum := 0.0
+	for i := range v1 {
+		sum += math.Abs(v1[i] - v2[i])
+	}
+	return sum
+}
+
+// JaccardSimilarity returns the Jaccard similarity of the sets of words of two texts
+// (size of the intersection / size of the union, the words are lowercased):
+// between 0 and 1, the higher, the more similar.
+func JaccardSimilarity(text1, text2 string) float64 {
+	words1 := wordSet(text1)
+	words2 := wordSet(text2)
+	if len(words1) == 0 && len(words2) == 0 {
+		return 1.0
+	}
+	intersection := 0
+	for word := range words1 {
+		if words2[word] {
+			intersection++
+		}
+	}
+	union := len(words1) + len(words2) - intersection
+	return float64(intersection) / float64(union)
+}
+
+func wordSet(text string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[word] = true
+	}
+	return words
+}
+
+// LevenshteinDistance returns the minimum number of single-character edits
+// (insertions, deletions or substitutions) to change text1 into text2.
+func LevenshteinDistance(text1, text2 string) int {
+	runes1 := []rune(text1)
+	runes2 := []rune(text2)
+
+	previous := make([]int, len(runes2)+1)
+	current := make([]int, len(runes2)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(runes1); i++ {
+		current[0] = i
+		for j := 1; j <= len(runes2); j++ {
+			cost := 1
+			if runes1[i-1] == runes2[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(runes2)]
+}
+
+// LevenshteinSimilarity returns 1 - LevenshteinDistance / length of the longest text:
+// between 0 and 1, the higher, the more similar.
+func LevenshteinSimilarity(text1, text2 string) float64 {
+	length := max(len([]rune(text1)), len([]rune(text2)))
+	if length == 0 {
+		return 1.0
+	}
+	return 1.0 - float64(LevenshteinDistance(text1, text2))/float64(length)
+}
+
+// sortByScore sorts the records, the most similar first, and returns the first max records.
+func sortByScore(records []VectorRecord, metric Metric, max int) []VectorRecord {
+	sort.Slice(records, func(i, j int) bool {
+		return metric.Better(records[i].Score, records[j].Score)
+	})
+	if len(records) < max {
+		return records
+	}
+	return records[:max]
+}
@@ -0,0 +1,112 @@
+package gollama
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestVectorMetrics(t *testing.T) {
+	v1 := []float64{1.0, 2.0, 3.0}
+	v2 := []float64{4.0, 6.0, 3.0}
+
+	if DotProduct(v1, v2) != 25.0 {
+		t.Fatal("😡 unexpected dot product:", DotProduct(v1, v2))
+	}
+	if EuclideanDistance(v1, v2) != 5.0 {
+		t.Fatal("😡 unexpected euclidean distance:", EuclideanDistance(v1, v2))
+	}
+	if ManhattanDistance(v1, v2) != 7.0 {
+		t.Fatal("😡 unexpected manhattan distance:", ManhattanDistance(v1, v2))
+	}
+	if math.Abs(CosineSimilarity(v1, v1)-1.0) > 1e-9 || CosineSimilarity([]float64{1, 0}, []float64{0, 1}) != 0.0 {
+		t.Fatal("😡 unexpected cosine similarity")
+	}
+}
+
+func TestTextMetrics(t *testing.T) {
+	if similarity := JaccardSimilarity("Captain James T. Kirk", "captain Jean-Luc Picard"); similarity != 1.0/7.0 {
+		t.Fatal("😡 unexpected jaccard similarity:", similarity)
+	}
+	if LevenshteinDistance("kitten", "sitting") != 3 || LevenshteinDistance("", "abc") != 3 || LevenshteinDistance("Spock", "Spock") != 0 {
+		t.Fatal("😡 unexpected levenshtein distance")
+	}
+	if similarity := LevenshteinSimilarity("Picard", "Picardo"); math.Abs(similarity-6.0/7.0) > 1e-9 {
+		t.Fatal("😡 unexpected levenshtein similarity:", similarity)
+	}
+}
+
+func TestSearchWithMetric(t *testing.T) {
+	collection, _ := NewCollections(nil).Create("crew", "", 2)
+	stores := map[string]MetricVectorStore{
+		"memory":     NewMemoryVectorStore(),
+		"hnsw":       NewHNSWVectorStore(HNSWConfig{}),
+		"quantized":  NewQuantizedVectorStore(Float64Precision),
+		"collection": collection,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			testSearchWithMetric(t, store)
+		})
+	}
+}
+
+func testSearchWithMetric(t *testing.T, store MetricVectorStore) {
+	store.Save(VectorRecord{Id: "kirk", Text: "James T. Kirk captain of the Enterprise", Embedding: []float64{1.0, 1.0}})
+	store.Save(VectorRecord{Id: "picard", Text: "Jean-Luc Picard captain of the Enterprise-D", Embedding: []float64{3.0, 0.0}})
+	store.Save(VectorRecord{Id: "spock", Text: "Spock science officer", Embedding: []float64{0.0, 5.0}})
+
+	question := VectorRecord{Text: "Who is the captain of the Enterprise?", Embedding: []float64{1.0, 0.0}}
+
+	// distance: the lower, the better
+	records, _ := store.SearchTopNSimilaritiesWithMetric(question, 2.0, 3, EuclideanMetric)
+	if len(records) != 2 || records[0].Id != "kirk" || records[0].Score != 1.0 || records[1].Id != "picard" {
+		t.Fatal("😡 unexpected euclidean results:", records)
+	}
+
+	records, _ = store.SearchTopNSimilaritiesWithMetric(question, 0.0, 1, DotProductMetric)
+	if len(records) != 1 || records[0].Id != "picard" || records[0].Score != 3.0 {
+		t.Fatal("😡 unexpected dot product results:", records)
+	}
+
+	records, _ = store.SearchTopNSimilaritiesWithMetric(question, 0.42, 3, JaccardMetric)
+	if len(records) != 1 || records[0].Id != "kirk" {
+		t.Fatal("😡 unexpected jaccard results:", records)
+	}
+
+	records, _ = store.SearchTopNSimilaritiesWithMetric(question, 0.9, 3, CosineMetric)
+	if len(records) != 1 || records[0].Id != "picard" || records[0].CosineDistance != records[0].Score {
+		t.Fatal("😡 unexpected cosine results:", records)
+	}
+}
+
+func TestCosineDistanceDimensionMismatch(t *testing.T) {
+	if similarity, err := CosineDistance([]float64{1.0, 0.0}, []float64{1.0, 0.0}); err != nil || similarity != 1.0 {
+		t.Fatal("😡 unexpected similarity:", similarity, err)
+	}
+	_, err := CosineDistance([]float64{1.0, 0.0}, []float64{1.0, 0.0, 0.0})
+	var dimensionError *DimensionError
+	if !errors.As(err, &dimensionError) || dimensionError.Expected != 2 || dimensionError.Actual != 3 {
+		t.Fatal("😡 expected a *DimensionError:", err)
+	}
+}
+
+func TestVectorMetricsLengthMismatch(t *testing.T) {
+	v1 := []float64{1.0, 2.0, 3.0}
+	v2 := []float64{1.0, 2.0}
+
+	if DotProduct(v1, v2) != 0.0 || DotProduct(v2, v1) != 0.0 || CosineSimilarity(v1, v2) != 0.0 {
+		t.Fatal("😡 unexpected similarity of vectors of different lengths")
+	}
+	if !math.IsInf(EuclideanDistance(v2, v1), 1) || !math.IsInf(ManhattanDistance(v2, v1), 1) {
+		t.Fatal("😡 unexpected distance between vectors of different lengths")
+	}
+
+	question := VectorRecord{Embedding: v2}
+	record := VectorRecord{Embedding: v1}
+	for _, metric := range []Metric{CosineMetric, DotProductMetric, EuclideanMetric, ManhattanMetric} {
+		if score := metric.Score(question, record); metric.Passes(score, 0.0) || metric.Passes(score, -1.0) {
+			t.Fatal("😡 a record of another dimension passes the limit:", metric, score)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package gollama
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// === Recursive text splitter ===
+
+// DefaultChunkSize is the default maximum length of a chunk (in characters).
+const DefaultChunkSize = 1000
+
+// DefaultSeparators are the separators of a RecursiveTextSplitter, tried in order:
+// paragraphs, lines, sentences, words, and characters for the words longer than a chunk.
+var DefaultSeparators = []string{"\n\n", "\n", ". ", "! ", "? ", " ", ""}
+
+// Chunk is a part of a text. Start and End are the byte offsets
+// of the chunk in the text: text[chunk.Start:chunk.End] == chunk.Text.
+type Chunk struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// RecursiveTextSplitter splits a text into chunks of at most ChunkSize characters.
+// The text is split on the first separator (ex: paragraphs), the parts that are still too long
+// are split on the next separator (ex: lines), and so on; then the consecutive parts are merged
+// into chunks as long as possible, so the chunks end on the largest possible boundaries.
+//
+// Two consecutive chunks share about ChunkOverlap characters (whole parts, taken from the end
+// of the previous chunk), so a sentence cut between two chunks is still in its context.
+type RecursiveTextSplitter struct {
+	ChunkSize    int      // maximum length of a chunk, in characters (DefaultChunkSize by default)
+	ChunkOverlap int      // maximum length of the overlap, in characters (limited to ChunkSize / 2)
+	Separators   []string // DefaultSeparators by default ("" splits on every character)
+}
+
+// SplitTextRecursive splits the text with a RecursiveTextSplitter using the default separators.
+func SplitTextRecursive(text string, chunkSize, chunkOverlap int) []Chunk {
+	splitter := RecursiveTextSplitter{ChunkSize: chunkSize, ChunkOverlap: chunkOverlap}
+	return splitter.Split(text)
+}
+
+// span is a part of the text: text[start:end], with length characters.
+type span struct {
+	start, end, length int
+}
+
+// Split returns the chunks of the text, with the spaces around them trimmed.
+func (splitter RecursiveTextSplitter) Split(text string) []Chunk {
+	size := splitter.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	overlap := min(max(splitter.ChunkOverlap, 0), size/2)
+	separators := splitter.Separators
+	if separators == nil {
+		separators = DefaultSeparators
+	}
+
+	parts := splitSpan(text, span{0, len(text), utf8.RuneCountInString(text)}, separators, size, nil)
+	return mergeSpans(text, parts, size, overlap)
+}
+
+// mergeSpans merges the consecutive parts into chunks of at most size characters
+// (a part longer than size is a chunk on its own), with an overlap of at most overlap characters.
+func mergeSpans(text string, parts []span, size, overlap int) []Chunk {
+	var chunks []Chunk
+	for first := 0; first < len(parts); {
+		// merge the parts as long as the chunk is not too long
+		last, length := first, 0
+		for last < len(parts) && (last == first || length+parts[last].length <= size) {
+			length += parts[last].length
+			last++
+		}
+		if chunk, ok := trimChunk(text, parts[first].start, parts[last-1].end); ok {
+			chunks = append(chunks, chunk)
+		}
+		if last == len(parts) {
+			break
+		}
+
+		// the next chunk starts with the last parts of this chunk (the overlap)
+		next, overlapLength := last, 0
+		for next-1 > first && overlapLength+parts[next-1].length <= overlap && overlapLength+parts[next-1].length+parts[last].length <= size {
+			overlapLength += parts[next-1].length
+			next--
+		}
+		first = next
+	}
+	return chunks
+}
+
+// splitSpan appends to parts the parts of the span, each part having at most size characters
+// (except if there is no separator left). The separators stay at the end of the parts,
+// so the parts are contiguous.
+func splitSpan(text string, current span, separators []string, size int, parts []span) []span {
+	if current.length <= size || len(separators) == 0 {
+		return append(parts, current)
+	}
+	segment := text[current.start:current.end]
+
+	// the first separator found in the segment
+	index := len(separators) - 1
+	for i, separator := range separators {
+		if separator == "" || strings.Contains(segment, separator) {
+			index = i
+			break
+		}
+	}
+	separator, remaining := separators[index], separators[index+1:]
+
+	if separator == "" {
+		for offset, r := range segment {
+			start := current.start + offset
+			parts = append(parts, span{start, start + utf8.RuneLen(r), 1})
+		}
+		return parts
+	}
+
+	start := current.start
+	for start < current.end {
+		end := current.end
+		if i := strings.Index(text[start:current.end], separator); i >= 0 {
+			end = start + i + len(separator)
+		}
+		part := span{start, end, utf8.RuneCountInString(text[start:end])}
+		parts = splitSpan(text, part, remaining, size, parts)
+		start = end
+	}
+	return parts
+}
+
+// trimChunk returns the chunk text[start:end] without the spaces around it,
+// or false if the chunk is blank.
+func trimChunk(text string, start, end int) (Chunk, bool) {
+	chunk := text[start:end]
+	trimmed := strings.TrimLeftFunc(chunk, unicode.IsSpace)
+	start += len(chunk) - len(trimmed)
+	trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	if trimmed == "" {
+		return Chunk{}, false
+	}
+	return Chunk{Text: trimmed, Start: start, End: start + len(trimmed)}, true
+}
@@ -0,0 +1,75 @@
+package gollama
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const splitterText = `Space: the final frontier. These are the voyages of the starship Enterprise.
+
+Its five-year mission: to explore strange new worlds. To seek out new life and new civilizations!
+To boldly go where no man has gone before.
+
+Captain's log, stardate 41153.7. Our destination is planet Deneb IV, beyond which lies the great unexplored mass of the galaxy.`
+
+func TestSplitTextRecursive(t *testing.T) {
+	for _, size := range []int{20, 50, 120, 1000} {
+		chunks := SplitTextRecursive(splitterText, size, 0)
+		for _, chunk := range chunks {
+			if utf8.RuneCountInString(chunk.Text) > size {
+				t.Fatal("😡 the chunk is too long:", size, chunk.Text)
+			}
+			if splitterText[chunk.Start:chunk.End] != chunk.Text {
+				t.Fatal("😡 unexpected offsets:", chunk)
+			}
+		}
+		// without overlap, all the words are kept once, in order
+		var words []string
+		for _, chunk := range chunks {
+			words = append(words, strings.Fields(chunk.Text)...)
+		}
+		if strings.Join(words, " ") != strings.Join(strings.Fields(splitterText), " ") {
+			t.Fatal("😡 the chunks do not cover the text:", size, words)
+		}
+	}
+
+	// the paragraphs are kept whole when they fit
+	chunks := SplitTextRecursive(splitterText, 180, 0)
+	if len(chunks) != 3 || !strings.HasPrefix(chunks[1].Text, "Its five-year mission") || !strings.HasSuffix(chunks[1].Text, "gone before.") {
+		t.Fatal("😡 unexpected chunks:", chunks)
+	}
+
+	// the sentences are kept whole when they fit
+	chunks = SplitTextRecursive(splitterText, 60, 0)
+	if chunks[0].Text != "Space: the final frontier." {
+		t.Fatal("😡 unexpected chunk:", chunks[0])
+	}
+}
+
+func TestSplitTextRecursiveOverlap(t *testing.T) {
+	text := "one two three four five six seven eight nine ten"
+	chunks := SplitTextRecursive(text, 15, 6)
+	expected := []string{"one two three", "three four", "four five six", "six seven", "seven eight", "eight nine ten"}
+	if len(chunks) != len(expected) {
+		t.Fatal("😡 unexpected chunks:", chunks)
+	}
+	for idx, chunk := range chunks {
+		if chunk.Text != expected[idx] || text[chunk.Start:chunk.End] != chunk.Text {
+			t.Fatal("😡 unexpected chunk:", idx, chunk)
+		}
+	}
+}
+
+func TestSplitTextRecursiveLongWord(t *testing.T) {
+	chunks := RecursiveTextSplitter{ChunkSize: 4}.Split("ab ççççççççç d")
+	expected := []string{"ab ç", "çççç", "çççç", "d"}
+	if len(chunks) != len(expected) {
+		t.Fatal("😡 unexpected chunks:", chunks)
+	}
+	for idx, chunk := range chunks {
+		if chunk.Text != expected[idx] {
+			t.Fatal("😡 unexpected chunk:", idx, chunk)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package gollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// StreamError is returned when Ollama sends an {"error": "..."} object
+// in the middle of a stream (ex: the model crashed during the generation).
+type StreamError struct {
+	Message string
+}
+
+func (e *StreamError) Error() string {
+	return "Error: stream: " + e.Message
+}
+
+// DecodeError is returned when a line of a stream cannot be decoded.
+// Line is the offending line.
+type DecodeError struct {
+	Line string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return "Error: unable to decode the stream line: " + e.Err.Error() + ": " + e.Line
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeStream reads the NDJSON stream of body, decodes every line into a T
+// and calls onChunk with it.
+// It stops at the end of the stream, at the first error returned by onChunk,
+// at the first error sent by Ollama (StreamError),
+// at the first line that cannot be decoded (DecodeError),
+// or when ctx is done (ctx.Err() is returned).
+func decodeStream[T any](ctx context.Context, body io.Reader, onChunk func(T) error) error {
+	reader := bufio.NewReader(body)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			// the request has been canceled while reading the body
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		// the last line is not always terminated by a newline
+		endOfStream := err == io.EOF
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var errorChunk struct {
+				Error string `json:"error"`
+			}
+			if jsonErr := json.Unmarshal(line, &errorChunk); jsonErr == nil && errorChunk.Error != "" {
+				return &StreamError{Message: errorChunk.Error}
+			}
+
+			var chunk T
+			if jsonErr := json.Unmarshal(line, &chunk); jsonErr != nil {
+				return &DecodeError{Line: string(line), Err: jsonErr}
+			}
+			// generate an error to stop the stream
+			if chunkErr := onChunk(chunk); chunkErr != nil {
+				return chunkErr
+			}
+		}
+
+		if endOfStream {
+			return nil
+		}
+	}
+}
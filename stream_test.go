@@ -0,0 +1,95 @@
+package gollama
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newStreamServer(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+}
+
+func TestChatStreamFullAnswer(t *testing.T) {
+	server := newStreamServer(
+		`{"model":"tinyllama","message":{"role":"assistant","content":"Hello"},"done":false}` + "\n" +
+			`{"model":"tinyllama","message":{"role":"assistant","content":" World"},"done":false}` + "\n" +
+			`{"model":"tinyllama","created_at":"2024-11-15T09:00:00Z","message":{"role":"assistant","content":""},"done":true,"done_reason":"length",` +
+			`"total_duration":3000000000,"load_duration":500000000,"prompt_eval_count":10,"prompt_eval_duration":500000000,"eval_count":40,"eval_duration":2000000000}`)
+	defer server.Close()
+
+	chunks := 0
+	fullAnswer, err := ChatStream(server.URL, Query{Model: "tinyllama"}, func(answer Answer) error {
+		chunks++
+		return nil
+	})
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if chunks != 3 {
+		t.Fatal("😡 expected 3 chunks, got:", chunks)
+	}
+	if fullAnswer.Message.Content != "Hello World" || fullAnswer.Message.Role != "assistant" || !fullAnswer.Done {
+		t.Fatal("😡 unexpected full answer:", fullAnswer.ToJsonString())
+	}
+	if fullAnswer.EvalCount != 40 || fullAnswer.TotalDuration != 3*time.Second || fullAnswer.CreatedAt.IsZero() {
+		t.Fatal("😡 metrics are missing:", fullAnswer.ToJsonString())
+	}
+	if fullAnswer.TokensPerSecond() != 20.0 || fullAnswer.PromptTokensPerSecond() != 20.0 {
+		t.Fatal("😡 unexpected throughput:", fullAnswer.TokensPerSecond(), fullAnswer.PromptTokensPerSecond())
+	}
+	if !fullAnswer.IsTruncated() {
+		t.Fatal("😡 the answer should be truncated")
+	}
+}
+
+func TestChatStreamErrors(t *testing.T) {
+	server := newStreamServer(
+		`{"model":"tinyllama","message":{"role":"assistant","content":"Hello"},"done":false}` + "\n" +
+			`{"error":"an unknown error was encountered while running the model"}` + "\n")
+	defer server.Close()
+
+	_, err := ChatStream(server.URL, Query{Model: "tinyllama"}, func(answer Answer) error { return nil })
+	var streamErr *StreamError
+	if !errors.As(err, &streamErr) {
+		t.Fatal("😡 expected a StreamError, got:", err)
+	}
+	if streamErr.Message != "an unknown error was encountered while running the model" {
+		t.Fatal("😡 unexpected message:", streamErr.Message)
+	}
+
+	server = newStreamServer(`{"model":"tinyllama","message":` + "\n")
+	defer server.Close()
+
+	called := false
+	_, err = ChatStream(server.URL, Query{Model: "tinyllama"}, func(answer Answer) error {
+		called = true
+		return nil
+	})
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatal("😡 expected a DecodeError, got:", err)
+	}
+	if decodeErr.Line != `{"model":"tinyllama","message":` || called {
+		t.Fatal("😡 unexpected decode error:", decodeErr)
+	}
+}
+
+func TestChatStreamStop(t *testing.T) {
+	server := newStreamServer(
+		`{"model":"tinyllama","message":{"role":"assistant","content":"Hello"},"done":false}` + "\n" +
+			`{"model":"tinyllama","message":{"role":"assistant","content":" World"},"done":false}` + "\n")
+	defer server.Close()
+
+	stop := errors.New("stop")
+	_, err := ChatStream(server.URL, Query{Model: "tinyllama"}, func(answer Answer) error {
+		return stop
+	})
+	if err != stop {
+		t.Fatal("😡 expected the error of onChunk, got:", err)
+	}
+}
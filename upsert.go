@@ -0,0 +1,106 @@
+package gollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// === Upsert ===
+
+// UpsertResult is the result of Upsert: the ids of the documents.
+type UpsertResult struct {
+	Inserted  []string // the new documents (embedded)
+	Updated   []string // the documents with a changed content or model (embedded again)
+	Unchanged []string // the documents with the same content and model (not embedded again)
+}
+
+// Upsert saves the documents in the store, creating the embeddings
+// only for the new documents and for the documents whose content has changed
+// (the ContentHash of their Text, or Prompt if Text is empty) or whose embedding model has changed.
+// The other fields (Reference, MetaData, Attributes...) of an unchanged document are updated
+// with its current embedding. The documents need an Id.
+//
+// Re-indexing a set of documents after an edit only calls Ollama for the edited documents.
+func (c *Client) Upsert(ctx context.Context, store VectorStore, model string, documents []VectorRecord, options ...EmbeddingsOption) (UpsertResult, error) {
+	var result UpsertResult
+	var toEmbed []VectorRecord
+	var inserted []bool
+
+	for _, document := range documents {
+		existing, err := store.Get(document.Id)
+		if errors.Is(err, ErrRecordNotFound) {
+			toEmbed = append(toEmbed, document)
+			inserted = append(inserted, true)
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		if existing.ContentHash != ContentHash(document) || existing.Model != model {
+			toEmbed = append(toEmbed, document)
+			inserted = append(inserted, false)
+			continue
+		}
+
+		// same content: keep the embedding, save the other fields if they have changed
+		document.Embedding = existing.Embedding
+		document.Model = existing.Model
+		document.Version = existing.Version
+		document.ContentHash = existing.ContentHash
+		document.CosineDistance = existing.CosineDistance
+		document.Score = existing.Score
+		if sameAttributes(document.Attributes, existing.Attributes) {
+			document.Attributes = existing.Attributes
+		}
+		if !reflect.DeepEqual(document, existing) {
+			if _, err := store.Save(document); err != nil {
+				return result, err
+			}
+		}
+		result.Unchanged = append(result.Unchanged, document.Id)
+	}
+
+	if len(toEmbed) == 0 {
+		return result, nil
+	}
+	texts := make([]string, len(toEmbed))
+	for idx, document := range toEmbed {
+		texts[idx] = recordText(document)
+	}
+	records, err := c.CreateEmbeddings(ctx, model, texts, options...)
+	if err != nil {
+		return result, err
+	}
+
+	for idx, document := range toEmbed {
+		document.Embedding = records[idx].Embedding
+		document.Model = model
+		if _, err := store.Save(document); err != nil {
+			return result, err
+		}
+		if inserted[idx] {
+			result.Inserted = append(result.Inserted, document.Id)
+		} else {
+			result.Updated = append(result.Updated, document.Id)
+		}
+	}
+	return result, nil
+}
+
+// sameAttributes reports whether the attributes are equal once encoded in JSON:
+// the stores keeping the records in JSON (FileVectorStore) return the numbers as float64.
+func sameAttributes(a, b map[string]interface{}) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	jsonA, errA := json.Marshal(a)
+	jsonB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(jsonA, jsonB)
+}
+
+func Upsert(ctx context.Context, url string, store VectorStore, model string, documents []VectorRecord, options ...EmbeddingsOption) (UpsertResult, error) {
+	return NewClient(url).Upsert(ctx, store, model, documents, options...)
+}
@@ -0,0 +1,84 @@
+package gollama
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpsert(t *testing.T) {
+	requests := 0
+	server := newEmbedServer(t, &requests)
+	defer server.Close()
+
+	store := NewMemoryVectorStore()
+	documents := []VectorRecord{
+		{Id: "kirk", Text: "James T. Kirk"},
+		{Id: "picard", Text: "Jean-Luc Picard"},
+	}
+	result, err := Upsert(context.Background(), server.URL, store, "all-minilm", documents)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(result.Inserted) != 2 || len(result.Updated) != 0 || len(result.Unchanged) != 0 || requests != 1 {
+		t.Fatal("😡 unexpected result:", result, requests)
+	}
+
+	// re-indexing unchanged documents does not call Ollama
+	result, err = Upsert(context.Background(), server.URL, store, "all-minilm", documents)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(result.Unchanged) != 2 || requests != 1 {
+		t.Fatal("😡 unexpected result:", result, requests)
+	}
+	if record, _ := store.Get("kirk"); record.Version != 1 {
+		t.Fatal("😡 the unchanged record should not be saved again:", record)
+	}
+
+	// only the edited document is embedded again, the metadata of the other one is updated
+	documents[0].Text = "Captain James T. Kirk"
+	documents[1].Reference = "tng.md"
+	result, err = Upsert(context.Background(), server.URL, store, "all-minilm", documents)
+	if err != nil {
+		t.Fatal("😡:", err)
+	}
+	if len(result.Updated) != 1 || result.Updated[0] != "kirk" || len(result.Unchanged) != 1 || requests != 2 {
+		t.Fatal("😡 unexpected result:", result, requests)
+	}
+	kirk, _ := store.Get("kirk")
+	if kirk.Version != 2 || kirk.Embedding[0] != float64(len("Captain James T. Kirk")) || kirk.Model != "all-minilm" {
+		t.Fatal("😡 unexpected record:", kirk)
+	}
+	picard, _ := store.Get("picard")
+	if picard.Version != 2 || picard.Reference != "tng.md" || picard.Embedding[0] != float64(len("Jean-Luc Picard")) {
+		t.Fatal("😡 unexpected record:", picard)
+	}
+}
+
+func TestUpsertAfterReload(t *testing.T) {
+	requests := 0
+	server := newEmbedServer(t, &requests)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	documents := []VectorRecord{
+		{Id: "kirk", Text: "James T. Kirk", Attributes: map[string]interface{}{"season": 1, "ships": []interface{}{1701}}},
+	}
+	for round := 0; round < 3; round++ {
+		// the attributes are read back from JSON: the numbers are float64
+		store, err := OpenFileVectorStore(path)
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		if _, err := Upsert(context.Background(), server.URL, store, "all-minilm", documents); err != nil {
+			t.Fatal("😡:", err)
+		}
+		store.Close()
+	}
+	store, _ := OpenFileVectorStore(path)
+	defer store.Close()
+	if record, _ := store.Get("kirk"); record.Version != 1 || requests != 1 {
+		t.Fatal("😡 the unchanged record should not be saved again:", record.Version, requests)
+	}
+}
@@ -0,0 +1,114 @@
+package gollama
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrRecordNotFound is returned (wrapped) by Get when the record does not exist.
+var ErrRecordNotFound = errors.New("Error: record not found")
+
+// ErrNilFilter is returned by DeleteWhere when the filter is nil
+// (use a filter matching all the records to delete them all).
+var ErrNilFilter = errors.New("Error: DeleteWhere needs a filter")
+
+func recordNotFound(id string) error {
+	return fmt.Errorf("%w: %q", ErrRecordNotFound, id)
+}
+
+// VectorStore is implemented by the vector stores (MemoryVectorStore, ...),
+// so the RAG code does not depend on a specific backend.
+// The vectorstoretest package provides a conformance test suite for the implementations.
+//
+// Get returns an error wrapping ErrRecordNotFound if the record does not exist,
+// Delete does nothing if the record does not exist.
+// Save sets the Version (1 for a new record, incremented at every update)
+// and the ContentHash of the record.
+type VectorStore interface {
+	Get(id string) (VectorRecord, error)
+	GetAll() ([]VectorRecord, error)
+	Save(vectorRecord VectorRecord) (VectorRecord, error)
+	Delete(id string) error
+	Count() (int, error)
+
+	// SearchSimilarities returns the records with a cosine similarity
+	// greater than or equal to limit (with the CosineDistance field set).
+	SearchSimilarities(embeddingFromQuestion VectorRecord, limit float64) ([]VectorRecord, error)
+	// SearchTopNSimilarities returns at most max records with a cosine similarity
+	// greater than or equal to limit, the most similar first.
+	SearchTopNSimilarities(embeddingFromQuestion VectorRecord, limit float64, max int) ([]VectorRecord, error)
+}
+
+// FilterableVectorStore is a VectorStore able to restrict the searches
+// to the records matching a Filter on their Attributes.
+type FilterableVectorStore interface {
+	VectorStore
+
+	SearchSimilaritiesWithFilter(embeddingFromQuestion VectorRecord, limit float64, filter Filter) ([]VectorRecord, error)
+	SearchTopNSimilaritiesWithFilter(embeddingFromQuestion VectorRecord, limit float64, max int, filter Filter) ([]VectorRecord, error)
+	// DeleteWhere deletes the records matching the filter and returns the number of deleted records.
+	// A nil filter is rejected with ErrNilFilter.
+	DeleteWhere(filter Filter) (int, error)
+}
+
+// MetricVectorStore is a VectorStore able to compare the records with the question
+// with another Metric than the cosine similarity (the Score field of the records is set).
+type MetricVectorStore interface {
+	VectorStore
+
+	SearchSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, metric Metric) ([]VectorRecord, error)
+	SearchTopNSimilaritiesWithMetric(embeddingFromQuestion VectorRecord, limit float64, max int, metric Metric) ([]VectorRecord, error)
+}
+
+var _ FilterableVectorStore = (*MemoryVectorStore)(nil)
+var _ MetricVectorStore = (*MemoryVectorStore)(nil)
+
+// embeddingSpec is the embedding model and dimension of a vector store,
+// recorded on the first insert. It is protected by the mutex of the store.
+type embeddingSpec struct {
+	model     string
+	dimension int
+}
+
+// check returns a *DimensionError or a *ModelError if the record (or the query)
+// does not match the embeddings of the store. A record without model matches any model.
+func (spec *embeddingSpec) check(record VectorRecord) error {
+	if spec.dimension > 0 && len(record.Embedding) != spec.dimension {
+		return &DimensionError{Id: record.Id, Expected: spec.dimension, Actual: len(record.Embedding)}
+	}
+	if spec.model != "" && record.Model != "" && record.Model != spec.model {
+		return &ModelError{Id: record.Id, Expected: spec.model, Actual: record.Model}
+	}
+	return nil
+}
+
+// update records the model and the dimension of the record if they are not known yet.
+func (spec *embeddingSpec) update(record VectorRecord) {
+	if spec.dimension == 0 {
+		spec.dimension = len(record.Embedding)
+	}
+	if spec.model == "" {
+		spec.model = record.Model
+	}
+}
+
+// ContentHash returns the SHA-256 hash (hexadecimal) of the content of the record
+// (Text, or Prompt if Text is empty): the embedding needs to be created again
+// only if the content hash has changed (see Upsert).
+func ContentHash(record VectorRecord) string {
+	hash := sha256.Sum256([]byte(recordText(record)))
+	return hex.EncodeToString(hash[:])
+}
+
+// nextVersion returns the record to save, with its Version and ContentHash set
+// (previous is the current version of the record, if it exists).
+func nextVersion(record VectorRecord, previous VectorRecord, exists bool) VectorRecord {
+	record.Version = 1
+	if exists {
+		record.Version = previous.Version + 1
+	}
+	record.ContentHash = ContentHash(record)
+	return record
+}
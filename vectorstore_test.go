@@ -0,0 +1,93 @@
+package gollama_test
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/parakeet-nest/gollama"
+	"github.com/parakeet-nest/gollama/vectorstoretest"
+)
+
+func TestMemoryVectorStoreConformance(t *testing.T) {
+	vectorstoretest.Run(t, func(t *testing.T) gollama.VectorStore {
+		return gollama.NewMemoryVectorStore()
+	})
+}
+
+func TestFileVectorStoreConformance(t *testing.T) {
+	vectorstoretest.Run(t, func(t *testing.T) gollama.VectorStore {
+		store, err := gollama.OpenFileVectorStore(filepath.Join(t.TempDir(), "store.jsonl"))
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
+
+// run with: go test -race -run TestMemoryVectorStoreConcurrency
+func TestMemoryVectorStoreConcurrency(t *testing.T) {
+	store := &gollama.MemoryVectorStore{} // the zero value is usable
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(2)
+		// indexing
+		go func(worker int) {
+			defer wg.Done()
+			for idx := 0; idx < 100; idx++ {
+				id := strconv.Itoa(worker*100 + idx)
+				if _, err := store.Save(gollama.VectorRecord{Id: id, Embedding: []float64{float64(idx), 1.0, 0.0}}); err != nil {
+					t.Error("😡:", err)
+				}
+				if idx%10 == 0 {
+					store.Delete(id)
+				}
+			}
+		}(worker)
+		// querying
+		go func() {
+			defer wg.Done()
+			for idx := 0; idx < 100; idx++ {
+				if _, err := store.SearchTopNSimilarities(vectorstoretest.Question, 0.5, 3); err != nil {
+					t.Error("😡:", err)
+				}
+				store.GetAll()
+				store.Count()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count, _ := store.Count(); count != 8*90 {
+		t.Fatal("😡 unexpected count:", count)
+	}
+}
+
+func TestHNSWVectorStoreConformance(t *testing.T) {
+	vectorstoretest.Run(t, func(t *testing.T) gollama.VectorStore {
+		return gollama.NewHNSWVectorStore(gollama.DefaultHNSWConfig())
+	})
+}
+
+func TestQuantizedVectorStoreConformance(t *testing.T) {
+	for _, precision := range []gollama.Precision{gollama.Float32Precision, gollama.Int8Precision} {
+		t.Run(precision.String(), func(t *testing.T) {
+			vectorstoretest.Run(t, func(t *testing.T) gollama.VectorStore {
+				return gollama.NewQuantizedVectorStore(precision)
+			})
+		})
+	}
+}
+
+func TestCollectionConformance(t *testing.T) {
+	vectorstoretest.Run(t, func(t *testing.T) gollama.VectorStore {
+		collection, err := gollama.NewCollections(nil).Create("docs", "all-minilm", 3)
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		return collection
+	})
+}
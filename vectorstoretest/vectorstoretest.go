@@ -0,0 +1,244 @@
+// Package vectorstoretest provides a conformance test suite
+// for the implementations of gollama.VectorStore.
+//
+//	func TestMyVectorStore(t *testing.T) {
+//		vectorstoretest.Run(t, func(t *testing.T) gollama.VectorStore {
+//			return NewMyVectorStore()
+//		})
+//	}
+package vectorstoretest
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/parakeet-nest/gollama"
+)
+
+// Records are the vector records saved by the suite.
+// The embeddings are small hand-made vectors, so the similarities are known:
+// "kirk" and "picard" are close to the question, "spock" is orthogonal.
+var Records = []gollama.VectorRecord{
+	{Id: "kirk", Prompt: "James T. Kirk", Text: "James T. Kirk", Embedding: []float64{1.0, 0.2, 0.0}},
+	{Id: "picard", Prompt: "Jean-Luc Picard", Text: "Jean-Luc Picard", Embedding: []float64{1.0, 0.0, 0.0}},
+	{Id: "spock", Prompt: "Spock", Text: "Spock", Embedding: []float64{0.0, 0.0, 1.0}},
+	{Id: "burnham", Prompt: "Michael Burnham", Text: "Michael Burnham", Embedding: []float64{0.5, 0.5, 0.5}},
+}
+
+// Question is the vector record used to search the similarities.
+var Question = gollama.VectorRecord{Id: "question", Prompt: "Who is Picard?", Embedding: []float64{1.0, 0.0, 0.0}}
+
+// Run runs the conformance test suite.
+// newStore is called for every sub-test and must return an empty store.
+func Run(t *testing.T, newStore func(t *testing.T) gollama.VectorStore) {
+	t.Run("SaveAndGet", func(t *testing.T) {
+		store := fill(t, newStore(t))
+
+		record, err := store.Get("picard")
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		if record.Id != "picard" || record.Prompt != "Jean-Luc Picard" || len(record.Embedding) != 3 {
+			t.Fatal("😡 unexpected record:", record)
+		}
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		store := fill(t, newStore(t))
+
+		if _, err := store.Get("sisko"); !errors.Is(err, gollama.ErrRecordNotFound) {
+			t.Fatal("😡 expected ErrRecordNotFound:", err)
+		}
+		store.Delete("kirk")
+		if _, err := store.Get("kirk"); !errors.Is(err, gollama.ErrRecordNotFound) {
+			t.Fatal("😡 expected ErrRecordNotFound:", err)
+		}
+		// deleting a missing record is not an error
+		if err := store.Delete("kirk"); err != nil {
+			t.Fatal("😡:", err)
+		}
+	})
+
+	t.Run("GetAllAndCount", func(t *testing.T) {
+		store := fill(t, newStore(t))
+
+		records, err := store.GetAll()
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		if ids(records) != "burnham,kirk,picard,spock" {
+			t.Fatal("😡 unexpected records:", ids(records))
+		}
+		count, err := store.Count()
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		if count != len(Records) {
+			t.Fatal("😡 unexpected count:", count)
+		}
+	})
+
+	t.Run("SaveReplaces", func(t *testing.T) {
+		store := fill(t, newStore(t))
+
+		updated := Records[0]
+		updated.Prompt = "Captain Kirk"
+		if _, err := store.Save(updated); err != nil {
+			t.Fatal("😡:", err)
+		}
+		record, err := store.Get("kirk")
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		if record.Prompt != "Captain Kirk" {
+			t.Fatal("😡 the record has not been replaced:", record)
+		}
+		if record.Version != 2 || record.ContentHash != gollama.ContentHash(updated) {
+			t.Fatal("😡 unexpected version:", record.Version, record.ContentHash)
+		}
+		if record, _ := store.Get("picard"); record.Version != 1 {
+			t.Fatal("😡 unexpected version:", record.Version)
+		}
+		if count, _ := store.Count(); count != len(Records) {
+			t.Fatal("😡 unexpected count:", count)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := fill(t, newStore(t))
+
+		if err := store.Delete("spock"); err != nil {
+			t.Fatal("😡:", err)
+		}
+		records, err := store.GetAll()
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		if ids(records) != "burnham,kirk,picard" {
+			t.Fatal("😡 unexpected records:", ids(records))
+		}
+		if count, _ := store.Count(); count != len(Records)-1 {
+			t.Fatal("😡 unexpected count:", count)
+		}
+	})
+
+	t.Run("DeleteWhere", func(t *testing.T) {
+		filterable, ok := newStore(t).(gollama.FilterableVectorStore)
+		if !ok {
+			t.Skip("the store is not a FilterableVectorStore")
+		}
+		fill(t, filterable)
+
+		if _, err := filterable.DeleteWhere(nil); !errors.Is(err, gollama.ErrNilFilter) {
+			t.Fatal("😡 a nil filter should be rejected:", err)
+		}
+		deleted, err := filterable.DeleteWhere(gollama.Or(
+			func(record gollama.VectorRecord) bool { return record.Id == "kirk" },
+			func(record gollama.VectorRecord) bool { return record.Id == "spock" },
+		))
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		records, _ := filterable.GetAll()
+		if deleted != 2 || ids(records) != "burnham,picard" {
+			t.Fatal("😡 unexpected records:", deleted, ids(records))
+		}
+		similarities, _ := filterable.SearchTopNSimilarities(Question, 0.1, 10)
+		if ids(similarities) != "burnham,picard" {
+			t.Fatal("😡 unexpected similarities:", ids(similarities))
+		}
+	})
+
+	t.Run("SearchSimilarities", func(t *testing.T) {
+		store := fill(t, newStore(t))
+
+		similarities, err := store.SearchSimilarities(Question, 0.9)
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		if ids(similarities) != "kirk,picard" {
+			t.Fatal("😡 unexpected similarities:", ids(similarities))
+		}
+		for _, similarity := range similarities {
+			if similarity.CosineDistance < 0.9 {
+				t.Fatal("😡 unexpected cosine similarity:", similarity.Id, similarity.CosineDistance)
+			}
+		}
+	})
+
+	t.Run("SearchTopNSimilarities", func(t *testing.T) {
+		store := fill(t, newStore(t))
+
+		similarities, err := store.SearchTopNSimilarities(Question, 0.1, 2)
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		if len(similarities) != 2 || similarities[0].Id != "picard" || similarities[1].Id != "kirk" {
+			t.Fatal("😡 unexpected similarities:", ids(similarities))
+		}
+
+		similarities, err = store.SearchTopNSimilarities(Question, 0.1, 10)
+		if err != nil {
+			t.Fatal("😡:", err)
+		}
+		if len(similarities) != 3 || similarities[2].Id != "burnham" {
+			t.Fatal("😡 unexpected similarities:", ids(similarities))
+		}
+	})
+
+	t.Run("DimensionAndModelMismatch", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.Save(gollama.VectorRecord{Id: "kirk", Model: "all-minilm", Embedding: []float64{1.0, 0.2, 0.0}}); err != nil {
+			t.Fatal("😡:", err)
+		}
+
+		var dimensionError *gollama.DimensionError
+		_, err := store.Save(gollama.VectorRecord{Id: "spock", Model: "all-minilm", Embedding: []float64{0.0, 1.0}})
+		if !errors.As(err, &dimensionError) || dimensionError.Expected != 3 || dimensionError.Actual != 2 {
+			t.Fatal("😡 expected a *DimensionError:", err)
+		}
+		var modelError *gollama.ModelError
+		_, err = store.Save(gollama.VectorRecord{Id: "spock", Model: "nomic-embed-text", Embedding: []float64{0.0, 0.0, 1.0}})
+		if !errors.As(err, &modelError) || modelError.Expected != "all-minilm" {
+			t.Fatal("😡 expected a *ModelError:", err)
+		}
+		if count, _ := store.Count(); count != 1 {
+			t.Fatal("😡 the mismatched records should be rejected:", count)
+		}
+		// a record without model is accepted
+		if _, err := store.Save(gollama.VectorRecord{Id: "picard", Embedding: []float64{1.0, 0.0, 0.0}}); err != nil {
+			t.Fatal("😡:", err)
+		}
+
+		_, err = store.SearchTopNSimilarities(gollama.VectorRecord{Embedding: []float64{1.0, 0.0}}, 0.5, 2)
+		if !errors.As(err, &dimensionError) {
+			t.Fatal("😡 expected a *DimensionError:", err)
+		}
+		_, err = store.SearchSimilarities(gollama.VectorRecord{Model: "nomic-embed-text", Embedding: []float64{1.0, 0.0, 0.0}}, 0.5)
+		if !errors.As(err, &modelError) {
+			t.Fatal("😡 expected a *ModelError:", err)
+		}
+	})
+}
+
+func fill(t *testing.T, store gollama.VectorStore) gollama.VectorStore {
+	t.Helper()
+	for _, record := range Records {
+		if _, err := store.Save(record); err != nil {
+			t.Fatal("😡:", err)
+		}
+	}
+	return store
+}
+
+// ids returns the sorted ids of the records ("id1,id2,...").
+func ids(records []gollama.VectorRecord) string {
+	var list []string
+	for _, record := range records {
+		list = append(list, record.Id)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}